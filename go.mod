@@ -1,6 +1,6 @@
 module github.com/agutikov/go-lisp-experiments
 
-go 1.18
+go 1.20
 
 require (
 	github.com/goccmack/gocc v0.0.0-20211213154817-7ea699349eca // indirect
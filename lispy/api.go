@@ -0,0 +1,177 @@
+// Package lispy is an embeddable Lisp interpreter.
+//
+// Stability: the embedding API - New and its options, Env methods Eval, Define, Lookup,
+// Set, Call, Child, Bindings, RegisterFunc, and conversion functions ParseStr, LispyStr,
+// Func, ToValue, FromValue, MarshalBinary, UnmarshalBinary - is stable:
+// it is only extended, existing signatures and behavior are not changed incompatibly.
+// Anything else exported from the package could change between versions.
+package lispy
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// Option configures the interpreter created by New
+type Option func(*Env)
+
+// New creates the interpreter with the standard environment,
+// by default it is bound to os.Stdin, os.Stdout, os.Stderr and the real file system
+func New(opts ...Option) *Env {
+	env := StdEnv()
+	for _, opt := range opts {
+		opt(env)
+	}
+	return env
+}
+
+// WithStdin binds the current input port to r
+func WithStdin(r io.Reader) Option {
+	return func(env *Env) {
+		env.ports.input = NewInputPort("stdin", r)
+	}
+}
+
+// WithStdout binds the current output port to w
+func WithStdout(w io.Writer) Option {
+	return func(env *Env) {
+		env.ports.output = NewOutputPort("stdout", w)
+	}
+}
+
+// WithStderr binds the current error port to w
+func WithStderr(w io.Writer) Option {
+	return func(env *Env) {
+		env.ports.error = NewOutputPort("stderr", w)
+	}
+}
+
+// WithFS gives the interpreter read-only file system instead of the real one
+func WithFS(fsys fs.FS) Option {
+	return func(env *Env) {
+		env.SetFS(fsys)
+	}
+}
+
+// WithClock replaces the system clock
+func WithClock(c Clock) Option {
+	return func(env *Env) {
+		env.SetClock(c)
+	}
+}
+
+// WithSeed makes random functions deterministic
+func WithSeed(seed int64) Option {
+	return func(env *Env) {
+		env.Seed(seed)
+	}
+}
+
+// WithCommandLine sets the script arguments returned by (command-line)
+func WithCommandLine(args []string) Option {
+	return func(env *Env) {
+		env.SetCommandLine(args)
+	}
+}
+
+// WithoutProcesses disables run-process and run-pipeline
+func WithoutProcesses() Option {
+	return func(env *Env) {
+		env.DisableProcesses()
+	}
+}
+
+// WithoutHost disables emergency-exit, environment variables and sleep on the system clock
+func WithoutHost() Option {
+	return func(env *Env) {
+		env.DisableHost()
+	}
+}
+
+type empty_fs struct{}
+
+func (empty_fs) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// WithoutIO isolates the interpreter: input is empty, output is discarded,
+// the file system is empty and read-only, processes and the host (see WithoutHost) are disabled.
+// Options given after it could bind some ports back, or set a clock to sleep on.
+func WithoutIO() Option {
+	return func(env *Env) {
+		env.ports = env_ports{
+			input:  NewInputPort("stdin", strings.NewReader("")),
+			output: NewOutputPort("stdout", io.Discard),
+			error:  NewOutputPort("stderr", io.Discard),
+		}
+		env.SetFS(empty_fs{})
+		env.DisableProcesses()
+		env.DisableHost()
+	}
+}
+
+// Lookup returns the value bound to name in this environment or its parents
+func (env *Env) Lookup(name string) (Any, bool) {
+	for e := env; e != nil; e = e.parent {
+		if v, ok := e.named_objects[name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Set changes the existing binding, like set! does
+func (env *Env) Set(name string, value Any) error {
+	for e := env; e != nil; e = e.parent {
+		if _, ok := e.named_objects[name]; ok {
+			e.named_objects[name] = value
+			return nil
+		}
+	}
+	return fmt.Errorf("Undefined symbol: %q", name)
+}
+
+// Call calls the procedure bound to name, arguments are converted by ToValue.
+// Errors raised by the procedure are returned as error.
+func (env *Env) Call(name string, args ...any) (result Any, err error) {
+	f, ok := env.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("Undefined symbol: %q", name)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, recovered_error(r)
+		}
+	}()
+	values := []Any{}
+	for _, a := range args {
+		values = append(values, ToValue(a))
+	}
+	return to_function(f)(values...), nil
+}
+
+// Error raised in lispy code as Go error, (exit) is returned as Exit
+func recovered_error(r any) error {
+	if err, ok := r.(error); ok {
+		return err
+	}
+	return fmt.Errorf("%v", r)
+}
+
+// Child creates nested environment, its definitions are not visible in the parent
+func (env *Env) Child() *Env {
+	return newEnv(env)
+}
+
+// Bindings returns the names defined in this environment, without parents
+func (env *Env) Bindings() []string {
+	names := []string{}
+	for name := range env.named_objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,42 @@
+package lispy
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+func to_char(c Any) Char {
+	switch v := c.(type) {
+	case Char:
+		return v
+	default:
+		panic("Invalid char: " + LispyStr(c))
+	}
+}
+
+func char_to_integer(args ...Any) Any {
+	return ast.IntNum(int64(to_char(one_arg("char->integer", args))))
+}
+
+func integer_to_char(args ...Any) Any {
+	arg := one_arg("integer->char", args)
+	n := to_int(arg).Value
+	if !n.IsInt64() || !utf8.ValidRune(rune(n.Int64())) || n.Int64() > unicode.MaxRune {
+		panic("Invalid code point: " + LispyStr(arg))
+	}
+	return Char(n.Int64())
+}
+
+func char_mapping(name string, f func(rune) rune) PureFunction {
+	return func(args ...Any) Any {
+		return Char(f(rune(to_char(one_arg(name, args)))))
+	}
+}
+
+func char_predicate(name string, f func(rune) bool) PureFunction {
+	return func(args ...Any) Any {
+		return Bool(f(rune(to_char(one_arg(name, args)))))
+	}
+}
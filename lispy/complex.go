@@ -0,0 +1,229 @@
+package lispy
+
+import (
+	"math"
+	"math/big"
+	"math/cmplx"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+func is_complex(n Any) bool {
+	switch n.(type) {
+	case ExactComplex, Complex:
+		return true
+	default:
+		return false
+	}
+}
+
+func to_exact_complex(n Any) ExactComplex {
+	switch v := n.(type) {
+	case ExactComplex:
+		return v
+	default:
+		return ExactComplex{Re: to_rat(n), Im: new(big.Rat)}
+	}
+}
+
+func to_complex128(n Any) complex128 {
+	switch v := n.(type) {
+	case Complex:
+		return v.Value
+	case ExactComplex:
+		re, _ := v.Re.Float64()
+		im, _ := v.Im.Float64()
+		return complex(re, im)
+	default:
+		return complex(to_float(n).Float64(), 0)
+	}
+}
+
+func exact_complex_add(a, b ExactComplex) ExactComplex {
+	return ExactComplex{
+		Re: new(big.Rat).Add(a.Re, b.Re),
+		Im: new(big.Rat).Add(a.Im, b.Im),
+	}
+}
+
+func exact_complex_sub(a, b ExactComplex) ExactComplex {
+	return ExactComplex{
+		Re: new(big.Rat).Sub(a.Re, b.Re),
+		Im: new(big.Rat).Sub(a.Im, b.Im),
+	}
+}
+
+// (a + bi)(c + di) = (ac - bd) + (ad + bc)i
+func exact_complex_mul(x, y ExactComplex) ExactComplex {
+	ac := new(big.Rat).Mul(x.Re, y.Re)
+	bd := new(big.Rat).Mul(x.Im, y.Im)
+	ad := new(big.Rat).Mul(x.Re, y.Im)
+	bc := new(big.Rat).Mul(x.Im, y.Re)
+	return ExactComplex{Re: ac.Sub(ac, bd), Im: ad.Add(ad, bc)}
+}
+
+// (a + bi)/(c + di) = ((ac + bd) + (bc - ad)i) / (c^2 + d^2)
+func exact_complex_div(x, y ExactComplex) ExactComplex {
+	den := new(big.Rat).Mul(y.Re, y.Re)
+	den.Add(den, new(big.Rat).Mul(y.Im, y.Im))
+	if den.Sign() == 0 {
+		panic("division by zero")
+	}
+	conj := ExactComplex{Re: y.Re, Im: new(big.Rat).Neg(y.Im)}
+	z := exact_complex_mul(x, conj)
+	return ExactComplex{Re: z.Re.Quo(z.Re, den), Im: z.Im.Quo(z.Im, den)}
+}
+
+// Square root of exact non-negative number, ok is false if it is not exact
+func exact_sqrt(r *big.Rat) (*big.Rat, bool) {
+	if r.Sign() < 0 {
+		return nil, false
+	}
+	num := new(big.Int).Sqrt(r.Num())
+	den := new(big.Int).Sqrt(r.Denom())
+	root := new(big.Rat).SetFrac(num, den)
+	if new(big.Rat).Mul(root, root).Cmp(r) != 0 {
+		return nil, false
+	}
+	return root, true
+}
+
+func exact_real(n Any) (*big.Rat, bool) {
+	switch n.(type) {
+	case Int, Rat, Decimal, Bool:
+		return to_rat(n), true
+	default:
+		return nil, false
+	}
+}
+
+func one_arg(name string, args []Any) Any {
+	if len(args) != 1 {
+		panic("'" + name + "' requires exactly 1 argument, provided: " + LispyStr(args))
+	}
+	return args[0]
+}
+
+func real_arg(name string, n Any) Any {
+	if num_kind(name, n) > num_float {
+		panic("Invalid '" + name + "' argument, real number expected: " + LispyStr(n))
+	}
+	return n
+}
+
+// Real function is used while the argument is in its domain, complex function otherwise
+func transcendental(name string, f func(float64) float64, f_c func(complex128) complex128, domain func(float64) bool) PureFunction {
+	return func(args ...Any) Any {
+		x := one_arg(name, args)
+		if num_kind(name, x) > num_float {
+			return ast.ComplexNum(f_c(to_complex128(x)))
+		}
+		v := to_float(x).Float64()
+		if domain == nil || domain(v) {
+			return ast.FloatNum(f(v))
+		}
+		return ast.ComplexNum(f_c(complex(v, 0)))
+	}
+}
+
+func atan(args ...Any) Any {
+	if len(args) == 2 {
+		y := to_float(real_arg("atan", args[0])).Float64()
+		x := to_float(real_arg("atan", args[1])).Float64()
+		return ast.FloatNum(math.Atan2(y, x))
+	}
+	return transcendental("atan", math.Atan, cmplx.Atan, nil)(args...)
+}
+
+// Square root of exact number is exact if possible: (sqrt 16/9) -> 4/3, (sqrt -4) -> +2i
+func sqrt(args ...Any) Any {
+	x := one_arg("sqrt", args)
+	if r, ok := exact_real(x); ok {
+		if root, ok := exact_sqrt(new(big.Rat).Abs(r)); ok {
+			if r.Sign() < 0 {
+				return ast.ExactComplexNum(new(big.Rat), root)
+			}
+			return ast.ExactRat(root)
+		}
+	}
+	return transcendental("sqrt", math.Sqrt, cmplx.Sqrt, func(x float64) bool { return x >= 0 })(x)
+}
+
+func make_rectangular(args ...Any) Any {
+	if len(args) != 2 {
+		panic("'make-rectangular' requires exactly 2 arguments, provided: " + LispyStr(args))
+	}
+	re := real_arg("make-rectangular", args[0])
+	im := real_arg("make-rectangular", args[1])
+
+	re_r, re_exact := exact_real(re)
+	im_r, im_exact := exact_real(im)
+	if re_exact && im_exact {
+		return ast.ExactComplexNum(re_r, im_r)
+	}
+	return ast.ComplexNum(complex(to_float(re).Float64(), to_float(im).Float64()))
+}
+
+func make_polar(args ...Any) Any {
+	if len(args) != 2 {
+		panic("'make-polar' requires exactly 2 arguments, provided: " + LispyStr(args))
+	}
+	mag := real_arg("make-polar", args[0])
+	ang := real_arg("make-polar", args[1])
+
+	if r, ok := exact_real(ang); ok && r.Sign() == 0 {
+		return mag
+	}
+	return ast.ComplexNum(cmplx.Rect(to_float(mag).Float64(), to_float(ang).Float64()))
+}
+
+func real_part(args ...Any) Any {
+	switch x := one_arg("real-part", args).(type) {
+	case ExactComplex:
+		return ast.ExactRat(x.Re)
+	case Complex:
+		return ast.FloatNum(real(x.Value))
+	default:
+		return real_arg("real-part", x)
+	}
+}
+
+func imag_part(args ...Any) Any {
+	switch x := one_arg("imag-part", args).(type) {
+	case ExactComplex:
+		return ast.ExactRat(x.Im)
+	case Complex:
+		return ast.FloatNum(imag(x.Value))
+	default:
+		real_arg("imag-part", x)
+		return ast.IntNum(0)
+	}
+}
+
+func magnitude(args ...Any) Any {
+	switch x := one_arg("magnitude", args).(type) {
+	case ExactComplex:
+		sq := new(big.Rat).Mul(x.Re, x.Re)
+		sq.Add(sq, new(big.Rat).Mul(x.Im, x.Im))
+		if root, ok := exact_sqrt(sq); ok {
+			return ast.ExactRat(root)
+		}
+		return ast.FloatNum(cmplx.Abs(to_complex128(x)))
+	case Complex:
+		return ast.FloatNum(cmplx.Abs(x.Value))
+	default:
+		if to_float(real_arg("magnitude", x)).Float64() < 0 {
+			return minus(x)
+		}
+		return x
+	}
+}
+
+func angle(args ...Any) Any {
+	x := one_arg("angle", args)
+	num_kind("angle", x)
+	if r, ok := exact_real(x); ok && r.Sign() >= 0 {
+		return ast.IntNum(0)
+	}
+	return ast.FloatNum(cmplx.Phase(to_complex128(x)))
+}
@@ -0,0 +1,315 @@
+package lispy
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/errors"
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/lexer"
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/parser"
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/token"
+)
+
+// ConfigError is a violation of configuration schema or an error in configuration file.
+// Line and Column are 0 when the position is unknown, Field is empty for errors not related to a field.
+type ConfigError struct {
+	File   string
+	Line   int
+	Column int
+	Field  string
+	Err    error
+}
+
+func (e *ConfigError) Error() string {
+	text := e.File
+	if e.Line > 0 {
+		text += fmt.Sprintf(":%d:%d", e.Line, e.Column)
+	}
+	text += ": "
+	if e.Field != "" {
+		text += e.Field + ": "
+	}
+	return text + e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigErrors are all violations found in configuration file
+type ConfigErrors []*ConfigError
+
+func (errs ConfigErrors) Error() string {
+	lines := []string{}
+	for _, e := range errs {
+		lines = append(lines, e.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// LoadConfig evaluates configuration file in a sandbox (see WithoutIO, opts are applied after it,
+// but can't give access to the host, see WithoutHost)
+// and decodes it into struct pointed by cfg.
+// Fields are taken from top-level definitions of the file, or from the alist
+// the file evaluates to if its last form is not a definition.
+// Field names and the schema are declared with lispy tags, e.g. `lispy:"port,required,min=1,max=65535"`:
+//   - required: the field must be defined;
+//   - min=N, max=N: bounds of a number, or of the length of a string or a list;
+//   - oneof=a|b|c: allowed values of a string or a symbol.
+//
+// Nested structs are read from alists and checked the same way.
+// All violations are returned as ConfigErrors with positions of the offending definitions.
+func LoadConfig(path string, cfg any, opts ...Option) error {
+	p := reflect.ValueOf(cfg)
+	if p.Kind() != reflect.Pointer || p.IsNil() || p.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("LoadConfig target must be a non-nil pointer to struct, got %T", cfg)
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	seq, err := parse_config(path, src)
+	if err != nil {
+		return err
+	}
+	positions := form_positions(src)
+	if len(positions) != len(seq) {
+		positions = nil
+	}
+	pos := func(i int) token.Pos {
+		if positions == nil {
+			return token.Pos{}
+		}
+		return positions[i]
+	}
+
+	opts = append(append([]Option{WithoutIO()}, opts...), WithoutHost())
+	env := New(opts...).Child()
+	defined := map[string]token.Pos{}
+	var result Any
+	for i, expr := range seq {
+		if err := eval_config_form(env, expr, &result); err != nil {
+			return ConfigErrors{config_error(path, pos(i), "", err)}
+		}
+		if d, ok := expr.(ast.Define); ok {
+			defined[d.Sym.Name] = pos(i)
+		}
+	}
+
+	d := config_decoder{file: path}
+	if len(seq) > 0 {
+		if _, ok := seq[len(seq)-1].(ast.Define); !ok {
+			items, ok := alist_items(result)
+			if !ok {
+				return ConfigErrors{config_error(path, pos(len(seq)-1), "", fmt.Errorf("configuration is not an alist: %s", LispyStr(result)))}
+			}
+			d.decode_struct(items, nil, pos(len(seq)-1), p.Elem(), "")
+			return d.result()
+		}
+	}
+
+	items := map[string]Any{}
+	for name := range defined {
+		items[name] = env.named_objects[name]
+	}
+	d.decode_struct(items, defined, token.Pos{}, p.Elem(), "")
+	return d.result()
+}
+
+func parse_config(path string, src []byte) (ast.Sequence, error) {
+	lex := lexer.NewLexer(src)
+	if lex.Scan().Type == token.EOF {
+		return ast.Sequence{}, nil
+	}
+	st, err := parser.NewParser().Parse(lexer.NewLexer(src))
+	if err != nil {
+		if e, ok := err.(*errors.Error); ok {
+			_, msg, _ := strings.Cut(e.Error(), "error: ")
+			return nil, ConfigErrors{config_error(path, e.ErrorToken.Pos, "", fmt.Errorf("%s", msg))}
+		}
+		return nil, err
+	}
+	return st.(ast.Sequence), nil
+}
+
+func eval_config_form(env *Env, expr Any, result *Any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recovered_error(r)
+		}
+	}()
+	*result = env.eval_expr(expr)
+	return nil
+}
+
+// Positions of top-level forms in src
+func form_positions(src []byte) []token.Pos {
+	open, close := token.TokMap.Type("("), token.TokMap.Type(")")
+	quote, unquote := token.TokMap.Type("'"), token.TokMap.Type(",")
+
+	positions := []token.Pos{}
+	depth, prefixed := 0, false
+	lex := lexer.NewLexer(src)
+	for tok := lex.Scan(); tok.Type != token.EOF && tok.Type != token.INVALID; tok = lex.Scan() {
+		if depth == 0 && !prefixed {
+			positions = append(positions, tok.Pos)
+		}
+		prefixed = tok.Type == quote || tok.Type == unquote
+		switch tok.Type {
+		case open:
+			depth++
+		case close:
+			depth--
+		}
+	}
+	return positions
+}
+
+func config_error(file string, pos token.Pos, field string, err error) *ConfigError {
+	return &ConfigError{File: file, Line: pos.Line, Column: pos.Column, Field: field, Err: err}
+}
+
+type config_decoder struct {
+	file string
+	errs ConfigErrors
+}
+
+func (d *config_decoder) fail(pos token.Pos, field string, format string, args ...any) {
+	d.errs = append(d.errs, config_error(d.file, pos, field, fmt.Errorf(format, args...)))
+}
+
+func (d *config_decoder) result() error {
+	if len(d.errs) > 0 {
+		return d.errs
+	}
+	return nil
+}
+
+// Decode alist items into struct fields, positions of items are taken from positions or default to pos
+func (d *config_decoder) decode_struct(items map[string]Any, positions map[string]token.Pos, pos token.Pos, dst reflect.Value, prefix string) {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, ok := field_name(f)
+		if !ok {
+			continue
+		}
+		rules := config_rules(f)
+		field := prefix + name
+		v, found := items[name]
+		if !found {
+			if _, required := rules["required"]; required {
+				d.fail(pos, field, "required field is missing")
+			}
+			continue
+		}
+		item_pos := pos
+		if p, ok := positions[name]; ok {
+			item_pos = p
+		}
+		d.decode_field(v, item_pos, dst.Field(i), field, rules)
+	}
+}
+
+func (d *config_decoder) decode_field(v Any, pos token.Pos, dst reflect.Value, field string, rules map[string]string) {
+	if err := check_config_rules(v, rules); err != nil {
+		d.fail(pos, field, "%s", err)
+		return
+	}
+
+	t := dst.Type()
+	if t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct && t.Elem() != time_type && v != nil && v != (ast.Nil{}) {
+		p := reflect.New(t.Elem())
+		before := len(d.errs)
+		d.decode_field(v, pos, p.Elem(), field, nil)
+		if len(d.errs) == before {
+			dst.Set(p)
+		}
+		return
+	}
+	if t.Kind() == reflect.Struct && t != time_type {
+		items, ok := alist_items(v)
+		if !ok {
+			d.fail(pos, field, "%s", conversion_error(v, t))
+			return
+		}
+		d.decode_struct(items, nil, pos, dst, field+".")
+		return
+	}
+
+	if err := from_value(v, dst); err != nil {
+		d.fail(pos, field, "%s", err)
+	}
+}
+
+// Schema rules from lispy tag options, e.g. `lispy:"level,required,oneof=debug|info"`
+func config_rules(f reflect.StructField) map[string]string {
+	rules := map[string]string{}
+	opts := strings.Split(f.Tag.Get("lispy"), ",")
+	for _, opt := range opts[1:] {
+		key, value, _ := strings.Cut(opt, "=")
+		rules[key] = value
+	}
+	return rules
+}
+
+func check_config_rules(v Any, rules map[string]string) error {
+	if values, ok := rules["oneof"]; ok {
+		s := ""
+		switch x := v.(type) {
+		case Str:
+			s = x.Value
+		case Symbol:
+			s = x.Name
+		default:
+			return fmt.Errorf("expected one of %s, got %s", values, LispyStr(v))
+		}
+		found := false
+		for _, allowed := range strings.Split(values, "|") {
+			found = found || s == allowed
+		}
+		if !found {
+			return fmt.Errorf("expected one of %s, got %s", values, LispyStr(v))
+		}
+	}
+
+	for _, bound := range []string{"min", "max"} {
+		limit, ok := rules[bound]
+		if !ok {
+			continue
+		}
+		l, ok := new(big.Rat).SetString(limit)
+		if !ok {
+			panic(fmt.Sprintf("Invalid %s in config schema: %s", bound, limit))
+		}
+
+		var x *big.Rat
+		what := "value"
+		switch s := v.(type) {
+		case Str:
+			x, what = big.NewRat(int64(len([]rune(s.Value))), 1), "length"
+		case List:
+			x, what = big.NewRat(int64(len(s)), 1), "length"
+		case ast.Nil:
+			x, what = new(big.Rat), "length"
+		default:
+			if f, ok := v.(Float); (ok && f.IsSpecial()) || num_kind_ok(v) > num_float {
+				return fmt.Errorf("expected number, string or list, got %s", LispyStr(v))
+			}
+			x = to_rat(v)
+		}
+
+		if bound == "min" && x.Cmp(l) < 0 {
+			return fmt.Errorf("%s %s is less than %s", what, x.RatString(), limit)
+		}
+		if bound == "max" && x.Cmp(l) > 0 {
+			return fmt.Errorf("%s %s is greater than %s", what, x.RatString(), limit)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package lispy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type test_config struct {
+	Name    string        `lispy:"name,required"`
+	Port    int           `lispy:"port,required,min=1,max=65535"`
+	Level   string        `lispy:"level,oneof=debug|info|error"`
+	Tags    []string      `lispy:"tags,max=2"`
+	Timeout time.Duration `lispy:"timeout"`
+	DB      struct {
+		Host string `lispy:"host,required"`
+		Pool int    `lispy:"pool,min=1"`
+	} `lispy:"db"`
+}
+
+func write_config(t *testing.T, src string) string {
+	path := filepath.Join(t.TempDir(), "config.lsp")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_LoadConfig(t *testing.T) {
+	var cfg test_config
+	path := write_config(t, `; service config
+(define base-port 8000)
+(define name "api")
+(define port (+ base-port 80))
+(define level 'info)
+(define tags (list "a" "b"))
+(define timeout (make-duration 1.5))
+(define db '((host "localhost") (pool 4)))
+`)
+	if err := LoadConfig(path, &cfg); err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+	if cfg.Name != "api" || cfg.Port != 8080 || cfg.Level != "info" || len(cfg.Tags) != 2 ||
+		cfg.Timeout != 1500*time.Millisecond || cfg.DB.Host != "localhost" || cfg.DB.Pool != 4 {
+		t.Errorf("Unexpected config: %+v", cfg)
+	}
+
+	cfg = test_config{}
+	path = write_config(t, "(define helper (lambda (p) (* p 2)))\n(list (list 'name \"x\") (list 'port (helper 40)) '(db ((host \"h\"))))")
+	if err := LoadConfig(path, &cfg); err != nil || cfg.Name != "x" || cfg.Port != 80 || cfg.DB.Host != "h" {
+		t.Errorf("Unexpected config from final value: %+v %v", cfg, err)
+	}
+
+	examples := [][]string{
+		{"(define name \"x\")\n  (define port 70000)",
+			"config.lsp:2:3: port: value 70000 is greater than 65535"},
+		{"(define port 1)\n(define level \"trace\")\n(define tags '(\"a\" \"b\" \"c\"))\n(define db '((pool 0)))",
+			"config.lsp: name: required field is missing\n" +
+				"config.lsp:2:1: level: expected one of debug|info|error, got \"trace\"\n" +
+				"config.lsp:3:1: tags: length 3 is greater than 2\n" +
+				"config.lsp:4:1: db.host: required field is missing\n" +
+				"config.lsp:4:1: db.pool: value 0 is less than 1"},
+		{"(define name 1)\n(define port \"80\")",
+			"config.lsp:1:1: name: Cannot convert 1 to string\n" +
+				"config.lsp:2:1: port: Cannot convert \"80\" to int"},
+		{"(define name \"x\")\n(define port (car 1))", "config.lsp:2:1: Invalid list"},
+		{"(define name \"x\")\n(define port 1))", "config.lsp:2:16: expected"},
+		{"(define name \"x\")\n(open-input-file \"/etc/passwd\")", "config.lsp:2:1: "},
+		{"'(1 2)", "config.lsp:1:1: configuration is not an alist"},
+		{"(define name \"x\")\n(define port 80)\n(emergency-exit 7)", "config.lsp:3:1: 'emergency-exit' is disabled"},
+		{"(define name (get-environment-variable \"HOME\"))", "config.lsp:1:1: 'get-environment-variable' is disabled"},
+		{"(set-environment-variable! \"LISPY_TEST_VAR\" \"x\")", "config.lsp:1:1: 'set-environment-variable!' is disabled"},
+		{"(get-environment-variables)", "config.lsp:1:1: 'get-environment-variables' is disabled"},
+	}
+	for _, test := range examples {
+		path := write_config(t, test[0])
+		err := LoadConfig(path, &test_config{})
+		var errs ConfigErrors
+		if !errors.As(err, &errs) {
+			t.Errorf("%q: expected ConfigErrors, got %v", test[0], err)
+			continue
+		}
+		msg := strings.ReplaceAll(err.Error(), path, "config.lsp")
+		if !strings.HasPrefix(msg, test[1]) {
+			t.Errorf("%q: unexpected error:\n%s\nexpected:\n%s", test[0], msg, test[1])
+		}
+	}
+
+	if err := LoadConfig("/nonexistent/config.lsp", &cfg); err == nil {
+		t.Errorf("Expected error for missing file")
+	}
+	if err := LoadConfig(path, cfg); err == nil {
+		t.Errorf("Expected error for non-pointer target")
+	}
+}
@@ -0,0 +1,211 @@
+package lispy
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+type csv_options struct {
+	delimiter   rune
+	comment     rune
+	header      bool
+	lazy        bool
+	trim        bool
+	lazy_quotes bool
+	crlf        bool
+}
+
+// Parses trailing option pairs: 'delimiter, 'comment, 'header, 'lazy, 'trim, 'lazy-quotes, 'crlf
+func parse_csv_options(name string, args []Any) csv_options {
+	if len(args)%2 != 0 {
+		panic("Invalid '" + name + "' options, name and value pairs expected: " + LispyStr(List(args)))
+	}
+	opts := csv_options{delimiter: ','}
+	for i := 0; i < len(args); i += 2 {
+		v := args[i+1]
+		switch to_symbol(args[i]).Name {
+		case "delimiter":
+			opts.delimiter = rune(to_char(v))
+		case "comment":
+			opts.comment = rune(to_char(v))
+		case "header":
+			opts.header = bool(if_test(v))
+		case "lazy":
+			opts.lazy = bool(if_test(v))
+		case "trim":
+			opts.trim = bool(if_test(v))
+		case "lazy-quotes":
+			opts.lazy_quotes = bool(if_test(v))
+		case "crlf":
+			opts.crlf = bool(if_test(v))
+		default:
+			panic("Invalid '" + name + "' option: " + LispyStr(args[i]))
+		}
+	}
+	return opts
+}
+
+func csv_row(record []string, header []string) List {
+	row := List{}
+	for i, field := range record {
+		if header != nil && i < len(header) {
+			row = append(row, List{Symbol{header[i]}, Str{field}})
+		} else {
+			row = append(row, Str{field})
+		}
+	}
+	return row
+}
+
+// Reads the port line by line, so csv reader doesn't take from the port
+// more than the records it returns, and the rest of the port could be read by other functions
+type csv_line_reader struct {
+	r *bufio.Reader
+}
+
+func (this csv_line_reader) Read(b []byte) (int, error) {
+	n := 0
+	for n < len(b) {
+		c, err := this.r.ReadByte()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		b[n] = c
+		n++
+		if c == '\n' {
+			break
+		}
+	}
+	return n, nil
+}
+
+// (csv-read string-or-port [option value]...) -> list of rows,
+// or with 'lazy option - function that returns the next row or eof-object
+func csv_read(args ...Any) Any {
+	n_args("csv-read", args, 1, -1, "at least 1 argument (string-or-port [option value]...)")
+	var src io.Reader
+	if p, ok := args[0].(*InputPort); ok {
+		src = csv_line_reader{p.reader}
+	} else {
+		src = strings.NewReader(to_str(args[0]))
+	}
+	opts := parse_csv_options("csv-read", args[1:])
+
+	r := csv.NewReader(src)
+	r.Comma = opts.delimiter
+	r.Comment = opts.comment
+	r.TrimLeadingSpace = opts.trim
+	r.LazyQuotes = opts.lazy_quotes
+	r.FieldsPerRecord = -1
+
+	var header []string
+	if opts.header {
+		record, err := r.Read()
+		if err != nil && err != io.EOF {
+			panic(err)
+		}
+		header = record
+	}
+
+	next := func(args ...Any) Any {
+		record, err := r.Read()
+		if err == io.EOF {
+			return EofObject{}
+		} else if err != nil {
+			panic(err)
+		}
+		return csv_row(record, header)
+	}
+	if opts.lazy {
+		return next
+	}
+
+	rows := List{}
+	for row := next(); row != (EofObject{}); row = next() {
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Keys of alist row, or nil if the row is not an alist
+func csv_header(row List) []string {
+	header := []string{}
+	for _, item := range row {
+		kv, ok := item.(List)
+		if !ok || len(kv) != 2 {
+			return nil
+		}
+		key, ok := kv[0].(Symbol)
+		if !ok {
+			return nil
+		}
+		header = append(header, key.Name)
+	}
+	return header
+}
+
+// (csv-write rows [port] [option value]...) - with 'header option rows are alists
+// and the header is taken from the keys of the first row
+func (env *Env) csv_write(args ...Any) Any {
+	n_args("csv-write", args, 1, -1, "at least 1 argument (rows [port] [option value]...)")
+	port := env.CurrentOutputPort()
+	rest := args[1:]
+	if len(rest) > 0 {
+		if p, ok := rest[0].(*OutputPort); ok {
+			port = p
+			rest = rest[1:]
+		}
+	}
+	opts := parse_csv_options("csv-write", rest)
+	format := env.NumberFormat()
+
+	w := csv.NewWriter(port.writer)
+	w.Comma = opts.delimiter
+	w.UseCRLF = opts.crlf
+
+	rows := to_list(args[0])
+	var header []string
+	if opts.header && len(rows) > 0 {
+		header = csv_header(to_list(rows[0]))
+		if header == nil {
+			panic("Invalid 'csv-write' row, alist expected with header: " + LispyStr(rows[0]))
+		}
+		if err := w.Write(header); err != nil {
+			panic(err)
+		}
+	}
+	for _, row := range rows {
+		record := []string{}
+		if header != nil {
+			// fields are looked up by key, rows may list them in any order
+			items, ok := alist_items(row)
+			if !ok {
+				panic("Invalid 'csv-write' row, alist expected with header: " + LispyStr(row))
+			}
+			for _, key := range header {
+				field, found := items[key]
+				if !found {
+					panic("Invalid 'csv-write' row, no '" + key + "' field: " + LispyStr(row))
+				}
+				record = append(record, display_str(field, format))
+			}
+		} else {
+			for _, field := range to_list(row) {
+				record = append(record, display_str(field, format))
+			}
+		}
+		if err := w.Write(record); err != nil {
+			panic(err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		panic(err)
+	}
+	return nil
+}
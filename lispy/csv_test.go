@@ -0,0 +1,30 @@
+package lispy
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_csv(t *testing.T) {
+	out := &strings.Builder{}
+	e := StdEnvWithIO(strings.NewReader("id;name\n1;\"a;b\"\n# skipped\n2;\"c\nd\"\nrest\n"), out, out)
+
+	examples := [][]string{
+		{"(csv-read \"a,b\\n\\\"x, \\\"\\\"y\\\"\\\"\\\",z\\n\")", "'((\"a\" \"b\") (\"x, \\\"y\\\"\" \"z\"))"},
+		{"(next)", "'((id \"1\") (name \"a;b\"))"},
+		{"(list (next) (read-line))", "'(((id \"2\") (name \"c\\nd\")) \"rest\")"},
+		{"(next)", "#<eof>"},
+		{"(csv-read \"a, b\" 'trim t)", "'((\"a\" \"b\"))"},
+		{"(begin (define o (open-output-string)) (csv-write (list (list \"a,b\" 1) (list \"q\\\"\" #\\c)) o) (get-output-string o))", "\"\\\"a,b\\\",1\\n\\\"q\\\"\\\"\\\",c\\n\""},
+	}
+	e.Eval(ParseStr("(define next (csv-read (current-input-port) 'delimiter #\\; 'header t 'comment #\\# 'lazy t))"))
+	run_eval_table(t, e, examples)
+
+	e.Eval(ParseStr("(csv-write (list (list (list 'id 1) (list 'name \"x\")) (list (list 'name \"y z\") (list 'id 2))) 'header t 'delimiter #\\tab)"))
+	if out.String() != "id\tname\n1\tx\n2\ty z\n" {
+		t.Errorf("Unexpected CSV output: %q", out.String())
+	}
+	run_error_table(t, e, map[string]string{
+		"(csv-write (list (list (list 'id 1) (list 'name \"x\")) (list (list 'id 2))) 'header t)": "no 'name' field",
+	})
+}
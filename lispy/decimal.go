@@ -0,0 +1,123 @@
+package lispy
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+// Rounding of the rational number to integer,
+// the tie is the case when remainder is exactly a half of the denominator
+type rounding_mode func(q *big.Int, tie bool) *big.Int
+
+// Ties go away from zero: 2.5 -> 3, -2.5 -> -3
+func round_half_up(q *big.Int, tie bool) *big.Int {
+	return q
+}
+
+// Ties go to even neighbour: 2.5 -> 2, 3.5 -> 4, -2.5 -> -2
+func round_half_even(q *big.Int, tie bool) *big.Int {
+	if tie && q.Bit(0) == 1 {
+		// q is rounded away from zero, move back towards zero
+		if q.Sign() > 0 {
+			return q.Sub(q, big.NewInt(1))
+		}
+		return q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+// Decimals with greater scale are not created, their digits would take too much memory
+const max_decimal_scale = 10000
+
+func check_decimal_scale(scale int) {
+	if scale < 0 || scale > max_decimal_scale {
+		panic(fmt.Sprintf("Invalid decimal scale: %d, should be 0..%d", scale, max_decimal_scale))
+	}
+}
+
+// Round rational number to the decimal with given scale,
+// the result is first rounded half away from zero and then corrected by the mode
+func round_decimal(r *big.Rat, scale int, mode rounding_mode) Decimal {
+	check_decimal_scale(scale)
+
+	n := new(big.Int).Mul(r.Num(), ast.Pow10(scale))
+	d := r.Denom()
+
+	q, m := new(big.Int).QuoRem(n, d, new(big.Int))
+	m.Abs(m).Lsh(m, 1)
+	c := m.Cmp(d)
+
+	if c >= 0 {
+		if n.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	return Decimal{Unscaled: mode(q, c == 0), Scale: scale}
+}
+
+func to_decimal(n Any) Decimal {
+	switch v := n.(type) {
+	case Decimal:
+		return v
+	default:
+		return Decimal{Unscaled: to_int(n).Value, Scale: 0}
+	}
+}
+
+// Same value with greater scale
+func decimal_rescale(d Decimal, scale int) *big.Int {
+	return new(big.Int).Mul(d.Unscaled, ast.Pow10(scale-d.Scale))
+}
+
+func decimal_add(a, b Decimal) Decimal {
+	scale := max(a.Scale, b.Scale)
+	n := new(big.Int).Add(decimal_rescale(a, scale), decimal_rescale(b, scale))
+	return Decimal{Unscaled: n, Scale: scale}
+}
+
+func decimal_sub(a, b Decimal) Decimal {
+	scale := max(a.Scale, b.Scale)
+	n := new(big.Int).Sub(decimal_rescale(a, scale), decimal_rescale(b, scale))
+	return Decimal{Unscaled: n, Scale: scale}
+}
+
+// Scale of the product is the sum of scales: 1.5 * 1.25 = 1.875
+func decimal_mul(a, b Decimal) Decimal {
+	check_decimal_scale(a.Scale + b.Scale)
+	return Decimal{Unscaled: new(big.Int).Mul(a.Unscaled, b.Unscaled), Scale: a.Scale + b.Scale}
+}
+
+// Quotient keeps the greater scale and is rounded half to even
+func decimal_div(a, b Decimal) Decimal {
+	if b.Unscaled.Sign() == 0 {
+		panic("division by zero")
+	}
+	return round_decimal(new(big.Rat).Quo(a.Rat(), b.Rat()), max(a.Scale, b.Scale), round_half_even)
+}
+
+// (round-half-even x scale) -> Decimal
+func decimal_rounding(name string, mode rounding_mode) PureFunction {
+	return func(args ...Any) Any {
+		if len(args) != 2 {
+			panic("'" + name + "' requires exactly 2 arguments (number scale), provided: " + LispyStr(args))
+		}
+		if num_kind(name, args[0]) > num_float {
+			panic("Invalid '" + name + "' argument, real number expected: " + LispyStr(args[0]))
+		}
+		scale := to_int(args[1]).Value
+		if !scale.IsInt64() || scale.Int64() > max_decimal_scale {
+			panic("Invalid decimal scale: " + LispyStr(args[1]) + ", should be 0.." + strconv.Itoa(max_decimal_scale))
+		}
+		return round_decimal(to_rat(args[0]), int(scale.Int64()), mode)
+	}
+}
+
+func decimal_scale(args ...Any) Any {
+	return ast.IntNum(int64(to_decimal(one_arg("decimal-scale", args)).Scale))
+}
@@ -2,6 +2,8 @@ package lispy
 
 import (
 	"fmt"
+	"io/fs"
+	"math/rand"
 
 	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
 )
@@ -9,6 +11,15 @@ import (
 type Env struct {
 	parent        *Env
 	named_objects map[string]Any
+	// used only in the root env
+	rng   *rand.Rand
+	ports env_ports
+	fsys  fs.FS // nil for the real file system
+
+	no_processes bool
+	no_host      bool
+	script_args  []string
+	clock        Clock // nil for the system clock
 }
 
 func (env *Env) Print() Any {
@@ -25,6 +36,11 @@ func newEnv(parent *Env) *Env {
 	return &e
 }
 
+// Define binds name to the value in this environment
+func (env *Env) Define(name string, value Any) {
+	env.named_objects[name] = value
+}
+
 func (env *Env) assign_vars(vars []ast.Symbol, values ...Any) {
 	if len(vars) != len(values) {
 		panic("Invalid number of values provided")
@@ -56,3 +72,20 @@ func (env *Env) env_lookup(s string) *Env {
 		panic("Undefined symbol: \"" + s + "\"")
 	}
 }
+
+// Number printing policy defined by float-format and float-digits variables
+func (env *Env) NumberFormat() NumberFormat {
+	name := to_symbol(env.symbol_lookup(Symbol{Name: "float-format"})).Name
+	mode, ok := ast.ParseFloatFormat(name)
+	if !ok {
+		panic("Invalid float-format: " + name)
+	}
+
+	v := env.symbol_lookup(Symbol{Name: "float-digits"})
+	digits := to_int(v).Value
+	if !digits.IsInt64() || digits.Sign() < 0 {
+		panic("Invalid float-digits: " + LispyStr(v))
+	}
+
+	return NumberFormat{Float: mode, Digits: int(digits.Int64())}
+}
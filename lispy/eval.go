@@ -2,6 +2,7 @@ package lispy
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
@@ -33,14 +34,30 @@ func (env *Env) eval_set(s ast.Set) Any {
 	return value
 }
 
-func (env *Env) eval_lambda(l ast.Lambda) Any {
-	// Return callable which will
-	return func(args ...Any) Any {
-		// Eval body in the new nested environment
-		e := newEnv(env)
-		e.assign_vars(l.Args, args...)
-		return e.eval_expr(l.Body)
+// Procedure made by lambda, it keeps the arguments to check arity of calls from Go
+type lambda struct {
+	args []ast.Symbol
+	body ast.Any
+	env  *Env
+}
+
+func (this *lambda) call(args ...Any) Any {
+	// Eval body in the new nested environment
+	e := newEnv(this.env)
+	e.assign_vars(this.args, args...)
+	return e.eval_expr(this.body)
+}
+
+func (this *lambda) String() string {
+	names := []string{}
+	for _, arg := range this.args {
+		names = append(names, arg.Name)
 	}
+	return "#<lambda (" + strings.Join(names, " ") + ")>"
+}
+
+func (env *Env) eval_lambda(l ast.Lambda) Any {
+	return &lambda{args: l.Args, body: l.Body, env: env}
 }
 
 func (env *Env) eval_quote_expr(q Any) Any {
@@ -87,12 +104,27 @@ func (env *Env) eval_list(lst List) Any {
 	}
 	head := lst[0]
 	tail := lst[1:]
+	if s, ok := head.(Symbol); ok && (s.Name == "." || s.Name == ".-") {
+		return env.eval_member(s.Name, tail)
+	}
 	f_value := env.eval_expr(head)
 	f := to_function(f_value)
 	args := env.eval_args(tail...)
 	return f(args...)
 }
 
+// (. obj Method args...) and (.- obj Field [value]), name of the member is not evaluated
+func (env *Env) eval_member(form string, tail []Any) Any {
+	args := tail
+	if len(tail) >= 2 {
+		args = append([]Any{env.eval_expr(tail[0]), tail[1]}, env.eval_args(tail[2:]...)...)
+	}
+	if form == "." {
+		return go_call(args...)
+	}
+	return go_field(args...)
+}
+
 func quote_if_list(value Any) Any {
 	switch v := value.(type) {
 	case List:
@@ -111,12 +143,12 @@ func (env *Env) eval_sequence(seq ast.Sequence) Any {
 		r = env.eval_expr(expr)
 
 		if if_test(env.symbol_lookup(ast.Symbol{"enable-trace"})) {
-			fmt.Printf("eval_expr():  %s  ->  %s \n", LispyStr(expr), LispyStr(r))
+			env.trace(fmt.Sprintf("eval_expr():  %s  ->  %s \n", LispyStr(expr), LispyStr(r)))
 		}
 
 		elapsed := time.Since(started)
 		if if_test(env.symbol_lookup(ast.Symbol{"enable-print-elapsed"})) {
-			fmt.Println(" elapsed: ", elapsed)
+			env.trace(fmt.Sprintln(" elapsed: ", elapsed))
 		}
 	}
 	return r
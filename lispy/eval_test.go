@@ -1,19 +1,48 @@
 package lispy
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
 )
 
+// Evaluates expressions one by one in env and compares printed results with expected ones
+func run_eval_table(t *testing.T, env *Env, tests [][]string) {
+	t.Helper()
+	for _, test := range tests {
+		res_str := LispyStr(env.Eval(ParseStr(test[0])), env.NumberFormat())
+		if res_str != test[1] {
+			t.Errorf("Not expected Eval() result: %q -> %q, expected: %q", test[0], res_str, test[1])
+		}
+	}
+}
+
+// Checks that each expression raises an error containing the message
+func run_error_table(t *testing.T, env *Env, tests map[string]string) {
+	t.Helper()
+	for expr, msg := range tests {
+		func() {
+			defer func() {
+				r := recover()
+				if r == nil || !strings.Contains(fmt.Sprint(r), msg) {
+					t.Errorf("Expected %q error for %q, got: %v", msg, expr, r)
+				}
+			}()
+			env.Eval(ParseStr(expr))
+		}()
+	}
+}
+
 func Test_EvalStr(t *testing.T) {
 	examples := [][]string{
 		{"nil", "nil"},
 		{"()", "'()"},
 		{"(quote (x 2 3))", "'(x 2 3)"},
 		{"(list 1 t nil ())", "'(1 t nil ())"},
-		{"(begin (define r 10) (* pi (* r r)))", "314.1592653589793115997963468544185161590576171875000000000"},
+		{"(begin (define r 10) (* pi (* r r)))", "314.1592653589793"},
 		{"(cons 1 ())", "'(1)"},
 		{"(cons 1 nil)", "'(1)"},
 		{"(cons 3 (cons 2 (cons 1 nil)))", "'(3 2 1)"},
@@ -29,6 +58,57 @@ func Test_EvalStr(t *testing.T) {
 		{"(cons nil nil)", "'(nil)"},
 		{"(and t 1 (cons nil nil) ())", "false"},
 
+		{"(+ 1/3 2/3)", "1"},
+		{"(- 1/2)", "-1/2"},
+		{"(* 2/3 3/4 #xff)", "255/2"},
+		{"(/ 1 2/3)", "3/2"},
+		{"(list (/ 1 2) (/ 6 3) (/ 1.5 2))", "'(1/2 2 0.75)"},
+		{"(list (/ 1.0 0) (/ -1 0.0) (/ 0.0 0) (/ +inf.0 0))", "'(+inf.0 -inf.0 +nan.0 +inf.0)"},
+		{"(+ 1/2 0.5)", "1.0"},
+		{"(list (< 1/3 0.3) (= 1/2 2/4) (> #b11 2))", "'(false t t)"},
+		{"(list (* -2 +inf.0) (- +inf.0 +inf.0) (< 0 +nan.0))", "'(-inf.0 +nan.0 false)"},
+
+		{"(list (+ 1+i 1-i) (* 3+4i 3-4i) (/ 1+i 1-i) (- 1+2i))", "'(2 25 +i -1-2i)"},
+		{"(* 2+i 0.5)", "1.0+0.5i"},
+		{"(list (real-part 3+4i) (imag-part 3+4i) (magnitude 3+4i) (angle 5))", "'(3 4 5 0)"},
+		{"(list (make-rectangular 1/2 -1) (make-rectangular 1.5 2) (make-polar 2 0))", "'(1/2-i 1.5+2.0i 2)"},
+		{"(list (sqrt -4) (sqrt 16/9) (sqrt -2.0) (log -1))", "'(+2i 4/3 +1.4142135623730951i +3.141592653589793i)"},
+		{"(list (= 1+2i 1+2i) (= 1+2i 1+2.0i))", "'(t false)"},
+
+		{"(list #d12.50 #d-0.05 (+ #d12.50 #d0.125) (- #d1.00 3) (* #d1.5 #d1.25))", "'(12.50 -0.05 12.625 -2.00 1.875)"},
+		{"(list (/ #d10.00 3) (/ #d2.00 #d3) (+ #d1.5 1/3) (= #d1.50 #d1.5))", "'(3.33 0.67 11/6 t)"},
+		{"(map round-half-even (list 2.5 3.5 -2.5 #d0.125 1/3) (list 0 0 0 2 4))", "'(2 4 -2 0.12 0.3333)"},
+		{"(map round-half-up (list 2.5 3.5 -2.5 #d0.125 2/3) (list 0 0 0 2 4))", "'(3 4 -3 0.13 0.6667)"},
+		{"(decimal-scale (round-half-up 1 2))", "2"},
+
+		{"(list #\\a #\\λ #\\space #\\x3bb #\\x7)", "'(#\\a #\\λ #\\space #\\λ #\\alarm)"},
+		{"(list (char->integer #\\λ) (integer->char 955) (char-upcase #\\ä) (char-downcase #\\Λ))", "'(955 #\\λ #\\Ä #\\λ)"},
+		{"(list (char-alphabetic? #\\λ) (char-alphabetic? #\\1) (char-numeric? #\\1) (char-whitespace? #\\tab))", "'(t false t t)"},
+		{"(begin (define π 3) (string-ref \"aλb\" 1))", "#\\λ"},
+
+		{"(list (string-length \"aλb\") (string-append \"a\" \"λ\" \"\") (substring \"привет\" 1 3) (substring \"abc\" 1))", "'(3 \"aλ\" \"ри\" \"bc\")"},
+		{"(list (string-split \" a b  c \") (string-split \"a,b,,c\" \",\") (string-join (list \"a\" \"b\") \", \"))", "'((\"a\" \"b\" \"c\") (\"a\" \"b\" \"\" \"c\") \"a, b\")"},
+		{"(list (string-index \"aλb\" #\\b) (string-contains \"привет\" \"ив\") (string-contains \"hello\" \"xyz\"))", "'(2 2 false)"},
+		{"(list (string-upcase \"λx\") (string-downcase \"ÄB\") (string-trim \"  x \") (string-trim \"--x-\" \"-\"))", "'(\"ΛX\" \"äb\" \"x\" \"x\")"},
+		{"(list (string->number \"1/2\") (string->number \"ff\" 16) (string->number \"abc\") (string->number \"t\"))", "'(1/2 255 false false)"},
+		{"(list (string->symbol \"foo\") (symbol->string 'bar) (string->list \"aλ\") (list->string (list #\\a #\\λ)))", "'(foo \"bar\" (#\\a #\\λ) \"aλ\")"},
+		{"(list (string<? \"a\" \"b\" \"c\") (string=? \"a\" \"a\" \"b\") (string>=? \"b\" \"a\") (string-ci=? \"ABC\" \"abc\"))", "'(t false t t)"},
+
+		{"(regexp-match \"(\\\\w+)@(\\\\w+)?(!)?\" \"жо jo@host\")", "'((\"jo@host\" 3 10) (\"jo\" 3 5) (\"host\" 6 10) nil)"},
+		{"(regexp-match-all \"((а)б)\" \"аб аб\")", "'(((\"аб\" 0 2) (\"аб\" 0 2) (\"а\" 0 1)) ((\"аб\" 3 5) (\"аб\" 3 5) (\"а\" 3 4)))"},
+		{"(list (regexp-match \"x\" \"abc\") (map car (regexp-match-all (regexp-compile \"[0-9]+\") \"a1b22c333\")))", "'(false ((\"1\" 1 2) (\"22\" 3 5) (\"333\" 6 9)))"},
+		{"(regexp-replace \"(\\\\d+)\" \"a1b22\" \"<$1>\")", "\"a<1>b<22>\""},
+		{"(regexp-replace \"(\\\\d)(\\\\d)?\" \"a1b23\" (lambda (m x y) (if y (string-append y x) x)))", "\"a1b32\""},
+		{"(regexp-replace \"\\\\B(a)\" \"baa a\" (lambda (m g) (string-upcase g)))", "\"bAA a\""},
+		{"(regexp-split \",\\\\s*\" \"a, b,c\")", "'(\"a\" \"b\" \"c\")"},
+		{"(regexp-compile \"a+\")", "#<regexp \"a+\">"},
+
+		{"(format \"~a and ~s\" \"str\" \"str\")", "\"str and \\\"str\\\"\""},
+		{"(format false \"~d ~x ~b ~o\" 255 255 5 8)", "\"255 ff 101 10\""},
+		{"(format \"~,2f|~8,3f|~f\" 3.14159 2 1/3)", "\"3.14|   2.000|0.3333333333333333\""},
+		{"(format \"[~5a][~5d]~%~~\" \"ab\" 42)", "\"[ab   ][   42]\\n~\""},
+		{"(format \"~{~a~^, ~}: ~{<~a=~s>~}\" (list 1 2 3) (list \"a\" #\\b \"c\" \"d\"))", "\"1, 2, 3: <a=#\\\\b><c=\\\"d\\\">\""},
+
 		{"'(1 ,(- 3 1) 3)", "'(1 2 3)"},
 		{"'(x (,x))", "'(x ((1 2 3 4)))"},
 	}
@@ -44,6 +124,14 @@ func Test_EvalStr(t *testing.T) {
 			t.Errorf("Not expected Eval() result: %q -> %q, expected: %q", expr, res_str, expected)
 		}
 	}
+
+	run_error_table(t, e, map[string]string{
+		"(/ 1 0)":                          "division by zero",
+		"(/ 1/2 0)":                        "division by zero",
+		"(round-half-even 1/3 2000000000)": "Invalid decimal scale: 2000000000, should be 0..10000",
+		"(round-half-up 1/3 -1)":           "Invalid decimal scale: -1",
+		"(* (round-half-up 1 6000) (round-half-up 1 6000))": "Invalid decimal scale: 12000",
+	})
 }
 
 func Test_define(t *testing.T) {
@@ -51,14 +139,16 @@ func Test_define(t *testing.T) {
 	lst := ParseStr(expr)
 	e := StdEnv()
 	e.Eval(lst)
-	v, ok := e.named_objects["foo"]
+	v, ok := e.Lookup("foo")
 	if !ok {
 		t.Errorf("define fails to update env")
 	}
-	f := func(args ...Any) Any { return nil }
-	if reflect.TypeOf(v) != reflect.TypeOf(f) {
+	if _, ok := v.(*lambda); !ok {
 		t.Errorf("Invalid env object type")
 	}
+	if r := LispyStr(v); r != "#<lambda (x)>" {
+		t.Errorf("Unexpected lambda string: %s", r)
+	}
 }
 
 func Test_set(t *testing.T) {
@@ -93,7 +183,7 @@ func Test_set(t *testing.T) {
 func Test_lambda(t *testing.T) {
 	f1 := Lambda("(lambda (x y) (/ (* x x) (* y y)))")
 	r1 := f1(ast.IntNum(2), ast.IntNum(4))
-	if !equal(r1, ast.FloatNum(0.25)) {
+	if LispyStr(r1) != "1/4" {
 		t.Errorf("Unexpected r1: %q", LispyStr(r1))
 	}
 
@@ -0,0 +1,108 @@
+package lispy_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/agutikov/go-lisp-experiments/lispy"
+)
+
+func ExampleNew() {
+	var out strings.Builder
+	env := lispy.New(lispy.WithoutIO(), lispy.WithStdout(&out))
+
+	env.Eval(lispy.ParseStr(`(display (list "sum" (+ 1 2)))`))
+	fmt.Println(out.String())
+
+	// the file system is empty and processes are not available
+	exists, _ := env.Call("file-exists?", "/etc/passwd")
+	_, err := env.Call("run-process", "ls", lispy.List{})
+	fmt.Println(lispy.LispyStr(exists), err)
+
+	// nor the host: exit of the process, environment variables and sleep
+	_, err = env.Call("emergency-exit", 1)
+	fmt.Println(err)
+	// Output:
+	// (sum 3)
+	// false 'run-process' is disabled
+	// 'emergency-exit' is disabled
+}
+
+func ExampleEnv_Call() {
+	env := lispy.New(lispy.WithoutIO())
+	env.Eval(lispy.ParseStr("(define fact (lambda (n) (if (<= n 1) 1 (* n (fact (- n 1))))))"))
+
+	r, err := env.Call("fact", 25)
+	fmt.Println(lispy.LispyStr(r), err)
+
+	_, err = env.Call("fact", "x")
+	fmt.Println(err)
+	// Output:
+	// 15511210043330985984000000 <nil>
+	// Invalid '<=' argument: "x"
+}
+
+func ExampleEnv_Define() {
+	env := lispy.New()
+	env.Define("limit", lispy.ToValue(10))
+	fmt.Println(lispy.LispyStr(env.Eval(lispy.ParseStr("(* limit 2)"))))
+
+	if err := env.Set("limit", lispy.ToValue(100)); err != nil {
+		fmt.Println(err)
+	}
+	v, ok := env.Lookup("limit")
+	fmt.Println(lispy.LispyStr(v), ok)
+
+	fmt.Println(env.Set("undefined", lispy.ToValue(1)))
+	// Output:
+	// 20
+	// 100 true
+	// Undefined symbol: "undefined"
+}
+
+func ExampleEnv_Child() {
+	env := lispy.New()
+	child := env.Child()
+	child.Eval(lispy.ParseStr("(define local 1)"))
+
+	_, in_child := child.Lookup("local")
+	_, in_parent := env.Lookup("local")
+	fmt.Println(in_child, in_parent, child.Bindings())
+	// Output:
+	// true false [local]
+}
+
+func ExampleExit() {
+	env := lispy.New(lispy.WithoutIO())
+	env.Eval(lispy.ParseStr("(define stop (lambda (code) (exit code)))"))
+
+	_, err := env.Call("stop", 3)
+	var exit lispy.Exit
+	if errors.As(err, &exit) {
+		fmt.Println("exit code:", exit.Code)
+	}
+	// Output:
+	// exit code: 3
+}
+
+func ExampleFunc() {
+	env := lispy.New(lispy.WithoutIO())
+
+	add, err := lispy.Func[func(int, int) int](env, "(lambda (a b) (+ a b))")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(add(2, 3))
+
+	// errors are returned if the function has error result
+	div, _ := lispy.Func[func(float64, float64) (float64, error)](env, "(lambda (a b) (/ a b))")
+	fmt.Println(div(1, 4))
+
+	_, err = lispy.Func[func(int) int](env, "(lambda (a b) (+ a b))")
+	fmt.Println(err)
+	// Output:
+	// 5
+	// 0.25 <nil>
+	// Func: func(int) int takes 1 arguments, lambda takes 2
+}
@@ -0,0 +1,164 @@
+package lispy
+
+import (
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+// SetFS makes file functions work with fsys instead of the real file system.
+// Since fs.FS is read-only, functions that modify files are not available then.
+func (env *Env) SetFS(fsys fs.FS) {
+	env.root().fsys = fsys
+}
+
+func (env *Env) open_file(name string) io.ReadCloser {
+	var f io.ReadCloser
+	var err error
+	if fsys := env.root().fsys; fsys != nil {
+		f, err = fsys.Open(name)
+	} else {
+		f, err = os.Open(name)
+	}
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func (env *Env) stat(name string) (fs.FileInfo, error) {
+	if fsys := env.root().fsys; fsys != nil {
+		return fs.Stat(fsys, name)
+	}
+	return os.Stat(name)
+}
+
+func (env *Env) read_dir(name string) ([]fs.DirEntry, error) {
+	if fsys := env.root().fsys; fsys != nil {
+		return fs.ReadDir(fsys, name)
+	}
+	return os.ReadDir(name)
+}
+
+// Panics if the interpreter works with read-only fs.FS
+func (env *Env) check_writable(name string) {
+	if env.root().fsys != nil {
+		panic("'" + name + "' is not available: file system is read-only")
+	}
+}
+
+func (env *Env) open_input_file(args ...Any) Any {
+	name := to_str(one_arg("open-input-file", args))
+	f := env.open_file(name)
+	p := NewInputPort(name, f)
+	p.closer = f
+	return p
+}
+
+func (env *Env) open_output_file(args ...Any) Any {
+	env.check_writable("open-output-file")
+	name := to_str(one_arg("open-output-file", args))
+	f, err := os.Create(name)
+	if err != nil {
+		panic(err)
+	}
+	p := NewOutputPort(name, f)
+	p.closer = f
+	return p
+}
+
+// (call-with-output-file filename proc) - port is closed after proc returns
+func (env *Env) call_with_output_file(args ...Any) Any {
+	n_args("call-with-output-file", args, 2, 2, "exactly 2 arguments (filename proc)")
+	f := to_function(args[1])
+	p := to_output_port(env.open_output_file(args[0]))
+	defer p.Close()
+	return f(p)
+}
+
+// (call-with-input-file filename proc) - port is closed after proc returns
+func (env *Env) call_with_input_file(args ...Any) Any {
+	n_args("call-with-input-file", args, 2, 2, "exactly 2 arguments (filename proc)")
+	f := to_function(args[1])
+	p := to_input_port(env.open_input_file(args[0]))
+	defer p.Close()
+	return f(p)
+}
+
+func close_port(args ...Any) Any {
+	var err error
+	switch p := one_arg("close-port", args).(type) {
+	case *InputPort:
+		err = p.Close()
+	case *OutputPort:
+		err = p.Close()
+	default:
+		panic("Invalid port: " + LispyStr(p))
+	}
+	if err != nil {
+		panic(err)
+	}
+	return nil
+}
+
+func (env *Env) file_exists(args ...Any) Any {
+	_, err := env.stat(to_str(one_arg("file-exists?", args)))
+	return Bool(err == nil)
+}
+
+func (env *Env) delete_file(args ...Any) Any {
+	env.check_writable("delete-file")
+	if err := os.Remove(to_str(one_arg("delete-file", args))); err != nil {
+		panic(err)
+	}
+	return nil
+}
+
+func (env *Env) rename_file(args ...Any) Any {
+	env.check_writable("rename-file")
+	n_args("rename-file", args, 2, 2, "exactly 2 arguments (old new)")
+	if err := os.Rename(to_str(args[0]), to_str(args[1])); err != nil {
+		panic(err)
+	}
+	return nil
+}
+
+func (env *Env) make_directory(args ...Any) Any {
+	env.check_writable("make-directory")
+	if err := os.Mkdir(to_str(one_arg("make-directory", args)), 0777); err != nil {
+		panic(err)
+	}
+	return nil
+}
+
+// (directory-list path) -> sorted list of file names
+func (env *Env) directory_list(args ...Any) Any {
+	entries, err := env.read_dir(to_str(one_arg("directory-list", args)))
+	if err != nil {
+		panic(err)
+	}
+	r := List{}
+	for _, e := range entries {
+		r = append(r, Str{e.Name()})
+	}
+	return r
+}
+
+func (env *Env) file_info(name string, args []Any) fs.FileInfo {
+	info, err := env.stat(to_str(one_arg(name, args)))
+	if err != nil {
+		panic(err)
+	}
+	return info
+}
+
+func (env *Env) file_size(args ...Any) Any {
+	return ast.IntNum(env.file_info("file-size", args).Size())
+}
+
+// (file-modification-time path) -> seconds since Unix epoch
+func (env *Env) file_modification_time(args ...Any) Any {
+	return ast.IntNum(env.file_info("file-modification-time", args).ModTime().Unix())
+}
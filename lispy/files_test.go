@@ -0,0 +1,48 @@
+package lispy
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func Test_files(t *testing.T) {
+	dir := t.TempDir()
+	e := StdEnv()
+	e.Define("dir", Str{dir + string(filepath.Separator)})
+
+	examples := [][]string{
+		{"(file-exists? (string-append dir \"a.txt\"))", "false"},
+		{"(call-with-output-file (string-append dir \"a.txt\") (lambda (p) (begin (display \"hello\\nλ\" p) 1)))", "1"},
+		{"(file-exists? (string-append dir \"a.txt\"))", "t"},
+		{"(file-size (string-append dir \"a.txt\"))", "8"},
+		{"(> (file-modification-time (string-append dir \"a.txt\")) 0)", "t"},
+		{"(define p (open-input-file (string-append dir \"a.txt\")))", "#<input-port " + dir + "/a.txt>"},
+		{"(list (read-line p) (read-char p) (eof-object? (read-char p)) (close-port p))", "'(\"hello\" #\\λ t nil)"},
+		{"(call-with-input-file (string-append dir \"a.txt\") read-line)", "\"hello\""},
+		{"(make-directory (string-append dir \"sub\"))", "nil"},
+		{"(rename-file (string-append dir \"a.txt\") (string-append dir \"b.txt\"))", "nil"},
+		{"(directory-list dir)", "'(\"b.txt\" \"sub\")"},
+		{"(delete-file (string-append dir \"b.txt\"))", "nil"},
+		{"(directory-list dir)", "'(\"sub\")"},
+	}
+	run_eval_table(t, e, examples)
+
+	e = StdEnv()
+	e.SetFS(fstest.MapFS{
+		"data/x.txt": {Data: []byte("from fs")},
+		"data/y.txt": {Data: []byte("")},
+	})
+	examples = [][]string{
+		{"(directory-list \"data\")", "'(\"x.txt\" \"y.txt\")"},
+		{"(call-with-input-file \"data/x.txt\" read-line)", "\"from fs\""},
+		{"(file-size \"data/x.txt\")", "7"},
+		{"(file-exists? \"data/z.txt\")", "false"},
+	}
+	run_eval_table(t, e, examples)
+
+	run_error_table(t, e, map[string]string{
+		"(delete-file \"data/x.txt\")":      "read-only",
+		"(open-output-file \"data/w.txt\")": "read-only",
+	})
+}
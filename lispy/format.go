@@ -0,0 +1,221 @@
+package lispy
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+// Human readable representation: strings and chars without quotes and escapes
+func display_str(v Any, format NumberFormat) string {
+	switch x := v.(type) {
+	case Str:
+		return x.Value
+	case Char:
+		return string(rune(x))
+	case List:
+		return "(" + strings.Join(ast.Map(func(a Any) string { return display_str(a, format) }, x), " ") + ")"
+	default:
+		return LispyStr(v, format)
+	}
+}
+
+// Directive: ~[width][,digits]X
+type format_directive struct {
+	width  int
+	digits int // -1 if not specified
+	kind   rune
+}
+
+type formatter struct {
+	out    strings.Builder
+	format NumberFormat
+}
+
+func pad_left(s string, width int) string {
+	if n := width - len([]rune(s)); n > 0 {
+		return strings.Repeat(" ", n) + s
+	}
+	return s
+}
+
+func pad_right(s string, width int) string {
+	if n := width - len([]rune(s)); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
+
+// Parse directive after '~', returns directive and the rest of the control string
+func parse_directive(ctrl []rune) (format_directive, []rune) {
+	d := format_directive{digits: -1}
+	i := 0
+	num := func() int {
+		start := i
+		for i < len(ctrl) && unicode.IsDigit(ctrl[i]) {
+			i++
+		}
+		n, _ := strconv.Atoi(string(ctrl[start:i]))
+		return n
+	}
+	d.width = num()
+	if i < len(ctrl) && ctrl[i] == ',' {
+		i++
+		d.digits = num()
+	}
+	if i >= len(ctrl) {
+		panic("'format' incomplete directive at the end of control string")
+	}
+	d.kind = unicode.ToLower(ctrl[i])
+	return d, ctrl[i+1:]
+}
+
+// Index of matching "~}" in the control string
+func find_iteration_end(ctrl []rune) int {
+	depth := 0
+	for i := 0; i+1 < len(ctrl); i++ {
+		if ctrl[i] != '~' {
+			continue
+		}
+		i++
+		for i < len(ctrl) && (unicode.IsDigit(ctrl[i]) || ctrl[i] == ',') {
+			i++
+		}
+		if i >= len(ctrl) {
+			break
+		}
+		switch ctrl[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i + 1
+			}
+			depth--
+		}
+	}
+	panic("'format' unterminated ~{ iteration")
+}
+
+func (f *formatter) integer(arg Any, radix int) string {
+	n, ok := arg.(Int)
+	if !ok {
+		return display_str(arg, f.format)
+	}
+	return n.Value.Text(radix)
+}
+
+func (f *formatter) float(d format_directive, arg Any) string {
+	num_kind("format ~f", arg)
+	if d.digits >= 0 {
+		return NumberFormat{Float: ast.FloatFixed, Digits: d.digits}.FormatFloat(to_float(arg))
+	}
+	return NumberFormat{Float: ast.FloatShortest}.FormatFloat(to_float(arg))
+}
+
+// Process control string consuming args, returns the rest of args.
+// The "~^" directive stops processing if there are no more args.
+func (f *formatter) run(ctrl []rune, args []Any) []Any {
+	next := func() Any {
+		if len(args) == 0 {
+			panic("'format' not enough arguments for control string")
+		}
+		a := args[0]
+		args = args[1:]
+		return a
+	}
+
+	for len(ctrl) > 0 {
+		c := ctrl[0]
+		ctrl = ctrl[1:]
+		if c != '~' {
+			f.out.WriteRune(c)
+			continue
+		}
+
+		var d format_directive
+		d, ctrl = parse_directive(ctrl)
+
+		switch d.kind {
+		case 'a':
+			f.out.WriteString(pad_right(display_str(next(), f.format), d.width))
+		case 's':
+			f.out.WriteString(pad_right(LispyStr(next(), f.format), d.width))
+		case 'd':
+			f.out.WriteString(pad_left(f.integer(next(), 10), d.width))
+		case 'x':
+			f.out.WriteString(pad_left(f.integer(next(), 16), d.width))
+		case 'o':
+			f.out.WriteString(pad_left(f.integer(next(), 8), d.width))
+		case 'b':
+			f.out.WriteString(pad_left(f.integer(next(), 2), d.width))
+		case 'f':
+			f.out.WriteString(pad_left(f.float(d, next()), d.width))
+		case '%':
+			f.out.WriteRune('\n')
+		case '~':
+			f.out.WriteRune('~')
+		case '^':
+			if len(args) == 0 {
+				return args
+			}
+		case '{':
+			end := find_iteration_end(ctrl)
+			body := ctrl[:end-2]
+			ctrl = ctrl[end:]
+			items := []Any(to_list(next()))
+			for len(items) > 0 {
+				rest := f.run(body, items)
+				if len(rest) == len(items) {
+					panic("'format' ~{ iteration doesn't consume arguments")
+				}
+				items = rest
+			}
+		default:
+			panic("'format' unknown directive: ~" + string(d.kind))
+		}
+	}
+
+	return args
+}
+
+func format_args(nf NumberFormat, ctrl string, args []Any) string {
+	f := formatter{format: nf}
+	rest := f.run([]rune(ctrl), args)
+	if len(rest) > 0 {
+		panic("'format' too many arguments: " + LispyStr(List(rest)))
+	}
+	return f.out.String()
+}
+
+// (format destination control args...)
+// destination: false - return a string, t - write to current output port, or output port,
+// if the first argument is a control string - return a string
+func (env *Env) format(args ...Any) Any {
+	if len(args) == 0 {
+		panic("'format' requires control string")
+	}
+	if ctrl, ok := args[0].(Str); ok {
+		return Str{format_args(env.NumberFormat(), ctrl.Value, args[1:])}
+	}
+	if len(args) < 2 {
+		panic("'format' requires control string, provided: " + LispyStr(args))
+	}
+
+	s := format_args(env.NumberFormat(), to_str(args[1]), args[2:])
+	switch dest := args[0].(type) {
+	case Bool:
+		if !bool(dest) {
+			return Str{s}
+		}
+		env.CurrentOutputPort().write(s)
+		return nil
+	case *OutputPort:
+		dest.write(s)
+		return nil
+	default:
+		panic("Invalid 'format' destination: " + LispyStr(args[0]))
+	}
+}
@@ -0,0 +1,91 @@
+package lispy
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Func adapts lispy procedure into typed Go function, e.g. Func[func(int, int) int](env, "(lambda (a b) (+ a b))").
+// src is evaluated in env and should give a procedure, arguments are converted by ToValue
+// and results by FromValue; several results are taken from the returned list.
+// If the last result of F is error, errors raised by the procedure are returned with it,
+// otherwise the call panics with them.
+// Number of arguments of a lambda src evaluates to is checked before returning.
+func Func[F any](env *Env, src string) (fn F, err error) {
+	t := reflect.TypeOf(&fn).Elem()
+	if t.Kind() != reflect.Func {
+		return fn, fmt.Errorf("Func: not a function type: %s", t)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = recovered_error(r)
+		}
+	}()
+	var f PureFunction
+	switch v := env.eval_sequence(ParseStr(src)).(type) {
+	case PureFunction:
+		f = v
+	case *lambda:
+		if !t.IsVariadic() && len(v.args) != t.NumIn() {
+			return fn, fmt.Errorf("Func: %s takes %d arguments, lambda takes %d", t, t.NumIn(), len(v.args))
+		}
+		f = v.call
+	default:
+		return fn, fmt.Errorf("Func: not a procedure: %s", src)
+	}
+
+	fn = reflect.MakeFunc(t, lispy_function(t, f)).Interface().(F)
+	return fn, nil
+}
+
+func lispy_function(t reflect.Type, f PureFunction) func([]reflect.Value) []reflect.Value {
+	returns_error := t.NumOut() > 0 && t.Out(t.NumOut()-1) == error_type
+	n_results := t.NumOut()
+	if returns_error {
+		n_results--
+	}
+
+	return func(in []reflect.Value) (out []reflect.Value) {
+		out = make([]reflect.Value, t.NumOut())
+		for i := range out {
+			out[i] = reflect.Zero(t.Out(i))
+		}
+		if returns_error {
+			defer func() {
+				if r := recover(); r != nil {
+					out[len(out)-1] = reflect.ValueOf(recovered_error(r))
+				}
+			}()
+		}
+
+		args := []Any{}
+		for i, v := range in {
+			if t.IsVariadic() && i == len(in)-1 {
+				for j := 0; j < v.Len(); j++ {
+					args = append(args, ToValue(v.Index(j).Interface()))
+				}
+			} else {
+				args = append(args, ToValue(v.Interface()))
+			}
+		}
+		r := f(args...)
+
+		results := []Any{r}
+		if n_results > 1 {
+			lst, ok := r.(List)
+			if !ok || len(lst) != n_results {
+				panic(fmt.Sprintf("Expected list of %d results: %s", n_results, LispyStr(r)))
+			}
+			results = lst
+		}
+		for i := 0; i < n_results; i++ {
+			v := reflect.New(t.Out(i)).Elem()
+			if err := from_value(results[i], v); err != nil {
+				panic(err)
+			}
+			out[i] = v
+		}
+		return out
+	}
+}
@@ -0,0 +1,64 @@
+package lispy
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_Func(t *testing.T) {
+	e := StdEnv()
+	e.Eval(ParseStr("(define swap (lambda (a b) (list b a)))"))
+
+	swap, err := Func[func(string, int) (int, string)](e, "swap")
+	if err != nil {
+		t.Fatalf("Func() failed: %v", err)
+	}
+	if n, s := swap("x", 1); n != 1 || s != "x" {
+		t.Errorf("Unexpected swap result: %v %v", n, s)
+	}
+
+	count, err := Func[func(...string) int](e, "(lambda (a b c) 3)")
+	if err != nil || count("a", "b", "c") != 3 {
+		t.Errorf("Unexpected variadic result: %v", err)
+	}
+
+	point, err := Func[func(test_point) (*test_point, error)](e, "(lambda (p) (list (list 'X (car (cdr (car p)))) (list 'Y 0)))")
+	if err != nil {
+		t.Fatalf("Func() failed: %v", err)
+	}
+	if p, err := point(test_point{7, 8}); err != nil || *p != (test_point{7, 0}) {
+		t.Errorf("Unexpected point result: %v %v", p, err)
+	}
+
+	bad, _ := Func[func(int) (string, error)](e, "(lambda (x) (car x))")
+	if _, err := bad(1); err == nil || !strings.Contains(err.Error(), "Invalid list") {
+		t.Errorf("Expected error from lispy, got: %v", err)
+	}
+	wrong_type, _ := Func[func(int) (string, error)](e, "(lambda (x) x)")
+	if _, err := wrong_type(1); err == nil {
+		t.Errorf("Expected result conversion error")
+	}
+
+	if _, err := Func[func(int) int](e, "(lambda (a b) a)"); err == nil || !strings.Contains(err.Error(), "lambda takes 2") {
+		t.Errorf("Expected arity error for lambda, got: %v", err)
+	}
+	if _, err := Func[func(int) []int](e, "swap"); err == nil || !strings.Contains(err.Error(), "lambda takes 2") {
+		t.Errorf("Expected arity error for defined procedure, got: %v", err)
+	}
+	if _, err := Func[func(int) int](e, "(begin (define f (lambda () 1)) f)"); err == nil || !strings.Contains(err.Error(), "lambda takes 0") {
+		t.Errorf("Expected arity error for evaluated procedure, got: %v", err)
+	}
+	if add, err := Func[func(int, int) int](e, "+"); err != nil || add(1, 2) != 3 {
+		t.Errorf("Unexpected builtin result: %v", err)
+	}
+
+	if _, err := Func[int](e, "swap"); err == nil {
+		t.Errorf("Expected error for non-function type")
+	}
+	if _, err := Func[func()](e, "1"); err == nil {
+		t.Errorf("Expected error for non-procedure")
+	}
+	if _, err := Func[func()](e, "(undefined"); err == nil {
+		t.Errorf("Expected parse error")
+	}
+}
@@ -0,0 +1,26 @@
+package lispy
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+// GenerateGo returns Go source file of package pkg declaring variable name
+// with the pre-built AST of lispy program, it could be run by Env.Eval without the parser.
+// source is the lispy file name mentioned in the "Code generated" header.
+func GenerateGo(pkg, name, source string, seq ast.Sequence) ([]byte, error) {
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "// Code generated by go-lispy gen from %s; DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(b, "package %s\n\n", pkg)
+	fmt.Fprintf(b, "import \"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast\"\n\n")
+	fmt.Fprintf(b, "var %s = %s\n", name, ast.GoSource(seq))
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("GenerateGo: %w", err)
+	}
+	return src, nil
+}
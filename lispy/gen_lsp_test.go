@@ -0,0 +1,99 @@
+// Code generated by go-lispy gen from testdata/gen.lsp; DO NOT EDIT.
+
+package lispy
+
+import "github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+
+var gen_lsp = ast.Sequence{
+	ast.Define{
+		Sym: ast.Symbol{Name: "fact"},
+		Value: ast.Lambda{
+			Args: []ast.Symbol{{Name: "n"}},
+			Body: ast.If{
+				Test: ast.List{
+					ast.Symbol{Name: "<="},
+					ast.Symbol{Name: "n"},
+					ast.Int{Value: ast.BigInt("1")},
+				},
+				PosBranch: ast.Int{Value: ast.BigInt("1")},
+				NegBranch: ast.List{
+					ast.Symbol{Name: "*"},
+					ast.Symbol{Name: "n"},
+					ast.List{
+						ast.Symbol{Name: "fact"},
+						ast.List{
+							ast.Symbol{Name: "-"},
+							ast.Symbol{Name: "n"},
+							ast.Int{Value: ast.BigInt("1")},
+						},
+					},
+				},
+			},
+		},
+	},
+	ast.Define{
+		Sym:   ast.Symbol{Name: "counter"},
+		Value: ast.Int{Value: ast.BigInt("0")},
+	},
+	ast.Set{
+		Sym: ast.Symbol{Name: "counter"},
+		Value: ast.List{
+			ast.Symbol{Name: "+"},
+			ast.Symbol{Name: "counter"},
+			ast.Int{Value: ast.BigInt("1")},
+		},
+	},
+	ast.Define{
+		Sym: ast.Symbol{Name: "numbers"},
+		Value: ast.List{
+			ast.Symbol{Name: "list"},
+			ast.Int{Value: ast.BigInt("123456789012345678901234567890")},
+			ast.Rat{Value: ast.BigRat("-3/4")},
+			ast.Float{Value: ast.BigRat("3/2")},
+			ast.Float{Value: ast.BigRat("0")},
+			ast.Float{Special: ast.SpecialFloat("+inf.0")},
+			ast.Decimal{Unscaled: ast.BigInt("1250"), Scale: 2},
+			ast.ExactComplex{Re: ast.BigRat("1"), Im: ast.BigRat("2")},
+			ast.Complex{Value: complex(1.5, 2.5)},
+			ast.Char('λ'),
+			ast.Str{Value: "a \"quoted\"\nstring"},
+		},
+	},
+	ast.Define{
+		Sym: ast.Symbol{Name: "data"},
+		Value: ast.Quote{Value: ast.List{
+			ast.Symbol{Name: "sym"},
+			ast.Nil{},
+			ast.Bool(true),
+			ast.Bool(false),
+			ast.List{
+				ast.Symbol{Name: "nested"},
+				ast.Unquote{Value: ast.Symbol{Name: "counter"}},
+			},
+			ast.List{},
+		}},
+	},
+	ast.Define{
+		Sym: ast.Symbol{Name: "thunk"},
+		Value: ast.Lambda{
+			Args: []ast.Symbol{},
+			Body: ast.Symbol{Name: "counter"},
+		},
+	},
+	ast.List{
+		ast.Symbol{Name: "list"},
+		ast.List{
+			ast.Symbol{Name: "fact"},
+			ast.Int{Value: ast.BigInt("20")},
+		},
+		ast.Symbol{Name: "counter"},
+		ast.List{
+			ast.Symbol{Name: "length"},
+			ast.Symbol{Name: "numbers"},
+		},
+		ast.Symbol{Name: "data"},
+		ast.List{
+			ast.Symbol{Name: "thunk"},
+		},
+	},
+}
@@ -0,0 +1,31 @@
+package lispy
+
+import (
+	"os"
+	"testing"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+//go:generate go run .. gen -pkg lispy -var gen_lsp -o gen_lsp_test.go testdata/gen.lsp
+
+func Test_GenerateGo(t *testing.T) {
+	seq := ParseFile("testdata/gen.lsp")
+	if ast.String(gen_lsp) != ast.String(seq) {
+		t.Errorf("Generated AST differs from parsed:\n%s\n%s", ast.String(gen_lsp), ast.String(seq))
+	}
+	r1 := LispyStr(StdEnv().Eval(gen_lsp))
+	r2 := LispyStr(StdEnv().Eval(seq))
+	if r1 != r2 || r1 != "'(2432902008176640000 1 10 (sym nil t false (nested 1) ()) 1)" {
+		t.Errorf("Unexpected result of generated AST: %s, parsed: %s", r1, r2)
+	}
+
+	src, err := GenerateGo("lispy", "gen_lsp", "testdata/gen.lsp", seq)
+	if err != nil {
+		t.Fatalf("GenerateGo() failed: %v", err)
+	}
+	committed, err := os.ReadFile("gen_lsp_test.go")
+	if err != nil || string(src) != string(committed) {
+		t.Errorf("gen_lsp_test.go is out of date, run go generate: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package lispy
+
+import (
+	"reflect"
+)
+
+// GoObject is an opaque host value, scripts access its fields and methods by reflection
+type GoObject struct {
+	value reflect.Value
+}
+
+// NewGoObject wraps Go value to be passed into lispy as is
+func NewGoObject(v any) GoObject {
+	return GoObject{reflect.ValueOf(v)}
+}
+
+// Value returns the wrapped Go value
+func (this GoObject) Value() any {
+	return this.value.Interface()
+}
+
+func (this GoObject) String() string {
+	return "#<go " + this.value.Type().String() + ">"
+}
+
+// Pointer-like values are the same if they point to the same object
+func (this GoObject) same(other GoObject) bool {
+	return same(this.value, other.value)
+}
+
+func to_go_object(name string, o Any) GoObject {
+	if v, ok := o.(GoObject); ok {
+		return v
+	}
+	panic("Invalid '" + name + "' argument, Go object expected: " + LispyStr(o))
+}
+
+// Plain data is converted into lispy values, other types are kept opaque
+func is_plain_type(t reflect.Type) bool {
+	switch t {
+	case big_int_type, big_rat_type, time_type, duration_type, bytes_type:
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	case reflect.Slice, reflect.Array:
+		return is_plain_type(t.Elem())
+	case reflect.Map:
+		return is_plain_type(t.Key()) && is_plain_type(t.Elem())
+	}
+	return false
+}
+
+func go_result(v reflect.Value) Any {
+	if is_plain_type(v.Type()) {
+		return to_value(v, value_path{})
+	}
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil
+	}
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	return GoObject{v}
+}
+
+// (. obj Method args...)
+func go_call(args ...Any) Any {
+	n_args(".", args, 2, -1, "at least 2 arguments (obj Method args...)")
+	obj := to_go_object(".", args[0])
+	name := to_symbol(args[1]).Name
+	m := obj.value.MethodByName(name)
+	if !m.IsValid() {
+		panic("No method " + name + " in " + obj.value.Type().String())
+	}
+	return go_function("."+name, m, go_result)(args[2:]...)
+}
+
+// (.- obj Field) reads the field, (.- obj Field value) writes it, obj should be a pointer to struct then
+func go_field(args ...Any) Any {
+	n_args(".-", args, 2, 3, "2 or 3 arguments (obj Field [value])")
+	obj := to_go_object(".-", args[0])
+	name := to_symbol(args[1]).Name
+	s := obj.value
+	for s.Kind() == reflect.Pointer || s.Kind() == reflect.Interface {
+		if s.IsNil() {
+			panic("Field " + name + " of nil " + obj.value.Type().String())
+		}
+		s = s.Elem()
+	}
+	if s.Kind() != reflect.Struct {
+		panic("Not a struct: " + obj.value.Type().String())
+	}
+	sf, ok := s.Type().FieldByName(name)
+	if !ok || !sf.IsExported() {
+		panic("No field " + name + " in " + obj.value.Type().String())
+	}
+	f, err := s.FieldByIndexErr(sf.Index)
+	if err != nil {
+		panic("Field " + name + " of " + obj.value.Type().String() + ": " + err.Error())
+	}
+	if len(args) == 2 {
+		return go_result(f)
+	}
+	if !f.CanSet() {
+		panic("Field " + name + " is not settable in " + obj.value.Type().String())
+	}
+	v := reflect.New(f.Type()).Elem()
+	if err := from_value(args[2], v); err != nil {
+		panic("Invalid '.-' value: " + err.Error())
+	}
+	f.Set(v)
+	return args[2]
+}
+
+func is_go_object(args ...Any) Any {
+	_, ok := one_arg("go-object?", args).(GoObject)
+	return Bool(ok)
+}
+
+// (go-type? obj "*pkg.Type")
+func is_go_type(args ...Any) Any {
+	n_args("go-type?", args, 2, 2, "exactly 2 arguments (obj type-name)")
+	obj, ok := args[0].(GoObject)
+	return Bool(ok && obj.value.Type().String() == to_str(args[1]))
+}
@@ -0,0 +1,90 @@
+package lispy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+type test_account struct {
+	Owner   string
+	Balance int
+	history []int
+}
+
+func (a *test_account) Deposit(n int) int {
+	a.Balance += n
+	a.history = append(a.history, n)
+	return a.Balance
+}
+
+func (a *test_account) Self() *test_account {
+	return a
+}
+
+func (a test_account) Describe(prefix string) (string, error) {
+	if prefix == "" {
+		return "", errors.New("empty prefix")
+	}
+	return prefix + a.Owner, nil
+}
+
+type test_savings struct {
+	*test_account
+	Rate int
+}
+
+func Test_GoObject(t *testing.T) {
+	acc := &test_account{Owner: "ann"}
+	e := StdEnv()
+	e.Define("acc", NewGoObject(acc))
+	e.Define("other", NewGoObject(&test_account{}))
+	e.Define("savings", NewGoObject(&test_savings{Rate: 3}))
+	ids := []int{1, 2}
+	e.Define("ids", NewGoObject(ids))
+	e.Define("ids2", NewGoObject(ids))
+	e.Define("names", NewGoObject(map[string]int{}))
+	e.Define("hook", NewGoObject(func() {}))
+	e.Define("boxed", NewGoObject(struct{ X any }{[]int{1}}))
+
+	examples := [][]string{
+		{"acc", "#<go *lispy.test_account>"},
+		{"(.- savings Rate)", "3"},
+		{"(list (eq? ids ids2) (eq? names names) (eq? hook hook) (eq? ids names) (eq? boxed boxed) (equal? hook hook))", "'(t t t false false t)"},
+		{"(begin (define l (list 1)) (define f (lambda (x) x)) (list (eq? (list 1) (list 1)) (eq? l l) (eq? car car) (eq? car cdr) (eq? f f) (eq? f (lambda (x) x))))", "'(false t t false t false)"},
+		{"(list (eq? '(1) '(1)) (eq? 'a 'a) (eq? \"s\" \"s\") (equal? car car) (equal? car cdr))", "'(false t t t false)"},
+		{"(. acc Deposit 10)", "10"},
+		{"(. acc Deposit 5)", "15"},
+		{"(.- acc Balance)", "15"},
+		{"(.- acc Owner \"bob\")", "\"bob\""},
+		{"(. acc Describe \"owner: \")", "\"owner: bob\""},
+		{"(list (eq? acc (. acc Self)) (eq? acc other) (equal? acc (. acc Self)) (eq? acc 1))", "'(t false t false)"},
+		{"(list (go-object? acc) (go-object? 1) (go-type? acc \"*lispy.test_account\") (go-type? acc \"lispy.test_account\"))", "'(t false t false)"},
+	}
+	run_eval_table(t, e, examples)
+	q := ast.Quote{Value: List{ast.IntNum(1)}}
+	if eq_p(q, q) != Bool(false) || equal(q, q) != Bool(false) {
+		t.Errorf("Quoted lists are not comparable")
+	}
+	if acc.Balance != 15 || acc.Owner != "bob" || len(acc.history) != 2 {
+		t.Errorf("Go object is not modified: %+v", acc)
+	}
+
+	errs := map[string]string{
+		"(. acc Describe \"\")":    "empty prefix",
+		"(. acc Withdraw 1)":       "No method Withdraw",
+		"(.- acc history)":         "No field history",
+		"(.- acc Balance \"x\")":   "Invalid '.-' value",
+		"(. 1 Deposit 1)":          "Go object expected",
+		"(.- savings Owner)":       "Field Owner of *lispy.test_savings",
+		"(.- savings Owner \"x\")": "nil pointer to embedded struct",
+	}
+	run_error_table(t, e, errs)
+
+	// Go objects are passed back to Go functions
+	e.RegisterFunc("owner", func(a *test_account) string { return a.Owner })
+	if r := LispyStr(e.Eval(ParseStr("(owner acc)"))); r != "\"bob\"" {
+		t.Errorf("Unexpected owner: %s", r)
+	}
+}
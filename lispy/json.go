@@ -0,0 +1,376 @@
+package lispy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+// Empty JSON object is a list of this tag, so it is not confused with empty array
+var json_empty_object = List{Symbol{"json-object"}}
+
+// JSON objects are alists of (key value) with symbol keys, arrays are lists
+func json_decode(dec *json.Decoder) Any {
+	tok, err := dec.Token()
+	if err != nil {
+		panic(err)
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		lst := List{}
+		for dec.More() {
+			if v == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					panic(err)
+				}
+				lst = append(lst, List{Symbol{key.(string)}, json_decode(dec)})
+			} else {
+				lst = append(lst, json_decode(dec))
+			}
+		}
+		// closing delimiter
+		if _, err := dec.Token(); err != nil {
+			panic(err)
+		}
+		if v == '{' && len(lst) == 0 {
+			return append(List{}, json_empty_object...)
+		}
+		return lst
+	case bool:
+		return Bool(v)
+	case json.Number:
+		return json_number(string(v))
+	case string:
+		return Str{v}
+	default:
+		// null
+		return nil
+	}
+}
+
+// Integers are exact, other numbers are inexact with exact decimal value
+func json_number(s string) Any {
+	if !strings.ContainsAny(s, ".eE") {
+		i, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			panic("Invalid JSON number: " + s)
+		}
+		return Int{i}
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic("Invalid JSON number: " + s)
+	}
+	return Float{Value: r}
+}
+
+func json_parse_from(r io.Reader) (Any, *json.Decoder) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return json_decode(dec), dec
+}
+
+// Reads the text of the next JSON value from r without reading ahead,
+// so the rest of the port could be read by other functions
+func read_json_text(r *bufio.Reader) string {
+	var b strings.Builder
+	depth := 0
+	in_string, escaped := false, false
+	for {
+		c, _, err := r.ReadRune()
+		if err == io.EOF {
+			if depth > 0 || in_string || b.Len() == 0 {
+				panic("Invalid JSON: unexpected end of input")
+			}
+			return b.String()
+		} else if err != nil {
+			panic(err)
+		}
+
+		switch {
+		case in_string:
+			b.WriteRune(c)
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				in_string = false
+				if depth == 0 {
+					return b.String()
+				}
+			}
+		case unicode.IsSpace(c):
+			if depth == 0 && b.Len() > 0 {
+				return b.String()
+			}
+			if depth > 0 {
+				b.WriteRune(c)
+			}
+		case strings.ContainsRune("{[]},\"", c) && depth == 0 && b.Len() > 0:
+			// end of number or literal at top level
+			r.UnreadRune()
+			return b.String()
+		default:
+			b.WriteRune(c)
+			switch c {
+			case '"':
+				in_string = true
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return b.String()
+				}
+			}
+		}
+	}
+}
+
+// (json-parse string-or-port) - from port reads the next JSON value
+func json_parse(args ...Any) Any {
+	src := one_arg("json-parse", args)
+	text := ""
+	if p, ok := src.(*InputPort); ok {
+		text = read_json_text(p.reader)
+	} else {
+		text = to_str(src)
+	}
+	v, dec := json_parse_from(strings.NewReader(text))
+	if _, err := dec.Token(); err != io.EOF {
+		panic("Invalid JSON: unexpected data after the value")
+	}
+	return v
+}
+
+type json_options struct {
+	indent    string
+	sort_keys bool
+}
+
+// Parses trailing option pairs: 'pretty, 'indent, 'sort-keys
+func parse_json_options(name string, args []Any) json_options {
+	if len(args)%2 != 0 {
+		panic("Invalid '" + name + "' options, name and value pairs expected: " + LispyStr(List(args)))
+	}
+	opts := json_options{}
+	for i := 0; i < len(args); i += 2 {
+		v := args[i+1]
+		switch to_symbol(args[i]).Name {
+		case "pretty":
+			if if_test(v) {
+				opts.indent = "  "
+			}
+		case "indent":
+			opts.indent = to_str(v)
+		case "sort-keys":
+			opts.sort_keys = bool(if_test(v))
+		default:
+			panic("Invalid '" + name + "' option: " + LispyStr(args[i]))
+		}
+	}
+	return opts
+}
+
+// Non-empty list of (symbol value) is encoded as JSON object
+func is_json_object(lst List) bool {
+	for _, item := range lst {
+		kv, ok := item.(List)
+		if !ok || len(kv) != 2 {
+			return false
+		}
+		if _, ok := kv[0].(Symbol); !ok {
+			return false
+		}
+	}
+	return len(lst) > 0
+}
+
+func json_string(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		panic(err)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Decimal digits of r if it has finite decimal representation
+func exact_decimal_string(r *big.Rat) (string, bool) {
+	d := new(big.Int).Set(r.Denom())
+	m := new(big.Int)
+	count := func(p int64) int {
+		n := 0
+		for {
+			q, _ := new(big.Int).QuoRem(d, big.NewInt(p), m)
+			if m.Sign() != 0 {
+				return n
+			}
+			d = q
+			n++
+		}
+	}
+	digits := max(count(2), count(5))
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return "", false
+	}
+	return r.FloatString(digits), true
+}
+
+// Exact numbers without finite decimal representation, like 1/3, are not representable
+func json_encode_number(v Any, exact bool) string {
+	r := to_rat(v)
+	s, ok := exact_decimal_string(r)
+	if !ok {
+		if exact {
+			panic("Not representable in JSON: " + LispyStr(v))
+		}
+		f, _ := r.Float64()
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	if !exact && !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+func json_encode(b *strings.Builder, v Any, opts json_options) {
+	switch x := v.(type) {
+	case nil, ast.Nil:
+		b.WriteString("null")
+	case Bool:
+		b.WriteString(strconv.FormatBool(bool(x)))
+	case Int:
+		b.WriteString(x.Value.String())
+	case Rat, Decimal:
+		b.WriteString(json_encode_number(x, true))
+	case Float:
+		if x.IsSpecial() {
+			panic("Not representable in JSON: " + LispyStr(v))
+		}
+		b.WriteString(json_encode_number(x, false))
+	case Str:
+		b.WriteString(json_string(x.Value))
+	case Symbol:
+		b.WriteString(json_string(x.Name))
+	case Char:
+		b.WriteString(json_string(string(rune(x))))
+	case List:
+		if equal(x, json_empty_object) {
+			b.WriteString("{}")
+			return
+		}
+		if is_json_object(x) {
+			json_encode_object(b, x, opts)
+			return
+		}
+		b.WriteByte('[')
+		for i, item := range x {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			json_encode(b, item, opts)
+		}
+		b.WriteByte(']')
+	default:
+		panic("Not representable in JSON: " + LispyStr(v))
+	}
+}
+
+func json_encode_object(b *strings.Builder, obj List, opts json_options) {
+	items := append(List{}, obj...)
+	if opts.sort_keys {
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].(List)[0].(Symbol).Name < items[j].(List)[0].(Symbol).Name
+		})
+	}
+	b.WriteByte('{')
+	for i, item := range items {
+		kv := item.(List)
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(json_string(kv[0].(Symbol).Name))
+		b.WriteByte(':')
+		json_encode(b, kv[1], opts)
+	}
+	b.WriteByte('}')
+}
+
+func json_stringify_value(v Any, opts json_options) string {
+	var b strings.Builder
+	json_encode(&b, v, opts)
+	if opts.indent == "" {
+		return b.String()
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(b.String()), "", opts.indent); err != nil {
+		panic(err)
+	}
+	return out.String()
+}
+
+// (json-stringify value [option value]...)
+func json_stringify(args ...Any) Any {
+	n_args("json-stringify", args, 1, -1, "at least 1 argument (value [option value]...)")
+	return Str{json_stringify_value(args[0], parse_json_options("json-stringify", args[1:]))}
+}
+
+// (json-write value [port] [option value]...)
+func (env *Env) json_write(args ...Any) Any {
+	n_args("json-write", args, 1, -1, "at least 1 argument (value [port] [option value]...)")
+	port := env.CurrentOutputPort()
+	opts := args[1:]
+	if len(opts) > 0 {
+		if p, ok := opts[0].(*OutputPort); ok {
+			port = p
+			opts = opts[1:]
+		}
+	}
+	port.write(json_stringify_value(args[0], parse_json_options("json-write", opts)))
+	return nil
+}
+
+// (json-ref value key-or-index...) - nil if there is no such key or index
+func json_ref(args ...Any) Any {
+	n_args("json-ref", args, 1, -1, "at least 1 argument (value key-or-index...)")
+	v := args[0]
+	for _, key := range args[1:] {
+		lst, ok := v.(List)
+		if !ok {
+			return nil
+		}
+		v = nil
+		switch k := key.(type) {
+		case Int:
+			if k.Value.IsInt64() && k.Value.Int64() >= 0 && k.Value.Int64() < int64(len(lst)) {
+				v = lst[k.Value.Int64()]
+			}
+		default:
+			name := ""
+			if s, ok := k.(Symbol); ok {
+				name = s.Name
+			} else {
+				name = to_str(k)
+			}
+			for _, item := range lst {
+				if kv, ok := item.(List); ok && len(kv) == 2 && kv[0] == (Symbol{name}) {
+					v = kv[1]
+					break
+				}
+			}
+		}
+	}
+	return v
+}
@@ -0,0 +1,37 @@
+package lispy
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_json(t *testing.T) {
+	out := &strings.Builder{}
+	e := StdEnvWithIO(strings.NewReader("{\"a\": [1, 2]} [true] 7\n\"s}\" -1.5]rest"), out, out)
+
+	examples := [][]string{
+		{"(define v (json-parse \"{\\\"name\\\": \\\"x<y\\\", \\\"big\\\": 123456789012345678901234567890, \\\"f\\\": 0.1, \\\"list\\\": [null, false, {}], \\\"e\\\": 1e2}\"))",
+			"'((name \"x<y\") (big 123456789012345678901234567890) (f 0.1) (list (nil false (json-object))) (e 100.0))"},
+		{"(json-ref v 'big)", "123456789012345678901234567890"},
+		{"(list (json-ref v \"list\" 1) (json-ref v 'missing) (json-ref v 'list 5))", "'(false nil nil)"},
+		{"(json-stringify v)", "\"{\\\"name\\\":\\\"x<y\\\",\\\"big\\\":123456789012345678901234567890,\\\"f\\\":0.1,\\\"list\\\":[null,false,{}],\\\"e\\\":100.0}\""},
+		{"(json-stringify (list (list 'b 1/4) (list 'a (list 1 \"s\"))) 'sort-keys t)", "\"{\\\"a\\\":[1,\\\"s\\\"],\\\"b\\\":0.25}\""},
+		{"(json-stringify (list (list \"a\" 1)))", "\"[[\\\"a\\\",1]]\""},
+		{"(json-stringify (json-parse \"{\\\"a\\\":{},\\\"b\\\":[]}\"))", "\"{\\\"a\\\":{},\\\"b\\\":[]}\""},
+		{"(json-stringify (/ 1.0 3))", "\"0.3333333333333333\""},
+		{"(json-parse (current-input-port))", "'((a (1 2)))"},
+		{"(list (json-parse (current-input-port)) (read-line))", "'((t) \" 7\")"},
+		{"(list (json-parse (current-input-port)) (read-char) (json-parse (current-input-port)) (read-line))", "'(\"s}\" #\\space -1.5 \"]rest\")"},
+	}
+	run_eval_table(t, e, examples)
+	run_error_table(t, e, map[string]string{
+		"(json-stringify 1/3)":                  "Not representable in JSON: 1/3",
+		"(json-parse \"[1, 2\")":                "unexpected end of JSON input",
+		"(json-parse (open-input-string \"\"))": "unexpected end of input",
+	})
+
+	e.Eval(ParseStr("(json-write (list (list 'k (list 1 2))) 'pretty t)"))
+	if out.String() != "{\n  \"k\": [\n    1,\n    2\n  ]\n}" {
+		t.Errorf("Unexpected pretty JSON: %q", out.String())
+	}
+}
@@ -17,7 +17,8 @@ func pasrse_bytes(bytes []byte) ast.Sequence {
 		// no valid tokens - return empty sequence
 		return ast.Sequence{}
 	}
-	lex.Reset()
+	// Reset() rewinds only the offset, but not the line and column
+	lex = lexer.NewLexer(bytes)
 
 	st, err := p.Parse(lex)
 	if err != nil {
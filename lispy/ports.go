@@ -0,0 +1,266 @@
+package lispy
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+type InputPort struct {
+	name   string
+	reader *bufio.Reader
+	closer io.Closer // nil if the port is not closed by close-port
+}
+
+type OutputPort struct {
+	name   string
+	writer io.Writer
+	closer io.Closer
+}
+
+// Returned by read functions at the end of input
+type EofObject struct{}
+
+func (this EofObject) String() string {
+	return "#<eof>"
+}
+
+func (this *InputPort) String() string {
+	return "#<input-port " + this.name + ">"
+}
+
+func (this *OutputPort) String() string {
+	return "#<output-port " + this.name + ">"
+}
+
+func NewInputPort(name string, r io.Reader) *InputPort {
+	return &InputPort{name: name, reader: bufio.NewReader(r)}
+}
+
+func NewOutputPort(name string, w io.Writer) *OutputPort {
+	return &OutputPort{name: name, writer: w}
+}
+
+func (this *InputPort) Close() error {
+	if this.closer == nil {
+		return nil
+	}
+	return this.closer.Close()
+}
+
+func (this *OutputPort) Close() error {
+	if this.closer == nil {
+		return nil
+	}
+	return this.closer.Close()
+}
+
+func (this *OutputPort) write(s string) {
+	if _, err := io.WriteString(this.writer, s); err != nil {
+		panic(err)
+	}
+}
+
+// Current ports of the interpreter
+type env_ports struct {
+	input  *InputPort
+	output *OutputPort
+	error  *OutputPort
+}
+
+// Standard environment with current ports bound to given reader and writers
+func StdEnvWithIO(stdin io.Reader, stdout io.Writer, stderr io.Writer) *Env {
+	return New(WithStdin(stdin), WithStdout(stdout), WithStderr(stderr))
+}
+
+func std_ports() env_ports {
+	return env_ports{
+		input:  NewInputPort("stdin", os.Stdin),
+		output: NewOutputPort("stdout", os.Stdout),
+		error:  NewOutputPort("stderr", os.Stderr),
+	}
+}
+
+func (env *Env) CurrentInputPort() *InputPort {
+	return env.root().ports.input
+}
+
+func (env *Env) CurrentOutputPort() *OutputPort {
+	return env.root().ports.output
+}
+
+func (env *Env) CurrentErrorPort() *OutputPort {
+	return env.root().ports.error
+}
+
+func to_input_port(p Any) *InputPort {
+	switch v := p.(type) {
+	case *InputPort:
+		return v
+	default:
+		panic("Invalid input port: " + LispyStr(p))
+	}
+}
+
+func to_output_port(p Any) *OutputPort {
+	switch v := p.(type) {
+	case *OutputPort:
+		return v
+	default:
+		panic("Invalid output port: " + LispyStr(p))
+	}
+}
+
+// Optional port argument at index i, current port by default
+func (env *Env) output_port_arg(args []Any, i int) *OutputPort {
+	if len(args) > i {
+		return to_output_port(args[i])
+	}
+	return env.CurrentOutputPort()
+}
+
+func (env *Env) input_port_arg(args []Any, i int) *InputPort {
+	if len(args) > i {
+		return to_input_port(args[i])
+	}
+	return env.CurrentInputPort()
+}
+
+// (display obj [port]) - strings and chars without quotes
+func (env *Env) display(args ...Any) Any {
+	n_args("display", args, 1, 2, "1 or 2 arguments (obj [port])")
+	env.output_port_arg(args, 1).write(display_str(args[0], env.NumberFormat()))
+	return nil
+}
+
+// (write obj [port]) - the same representation as printed by REPL
+func (env *Env) write(args ...Any) Any {
+	n_args("write", args, 1, 2, "1 or 2 arguments (obj [port])")
+	env.output_port_arg(args, 1).write(LispyStr(args[0], env.NumberFormat()))
+	return nil
+}
+
+func (env *Env) write_string(args ...Any) Any {
+	n_args("write-string", args, 1, 2, "1 or 2 arguments (string [port])")
+	env.output_port_arg(args, 1).write(to_str(args[0]))
+	return nil
+}
+
+func (env *Env) write_char(args ...Any) Any {
+	n_args("write-char", args, 1, 2, "1 or 2 arguments (char [port])")
+	env.output_port_arg(args, 1).write(string(rune(to_char(args[0]))))
+	return nil
+}
+
+func (env *Env) newline(args ...Any) Any {
+	n_args("newline", args, 0, 1, "0 or 1 argument ([port])")
+	env.output_port_arg(args, 0).write("\n")
+	return nil
+}
+
+// ReadLine reads the next line without end of line, it returns io.EOF at the end of input
+func (this *InputPort) ReadLine() (string, error) {
+	line, err := this.reader.ReadString('\n')
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), err
+}
+
+// (read-line [port]) -> string without end of line or eof object
+func (env *Env) read_line(args ...Any) Any {
+	n_args("read-line", args, 0, 1, "0 or 1 argument ([port])")
+	line, err := env.input_port_arg(args, 0).ReadLine()
+	if err == io.EOF {
+		return EofObject{}
+	} else if err != nil {
+		panic(err)
+	}
+	return Str{line}
+}
+
+func read_rune(p *InputPort, peek bool) Any {
+	r, _, err := p.reader.ReadRune()
+	if err == io.EOF {
+		return EofObject{}
+	} else if err != nil {
+		panic(err)
+	}
+	if peek {
+		p.reader.UnreadRune()
+	}
+	return Char(r)
+}
+
+func (env *Env) read_char(args ...Any) Any {
+	n_args("read-char", args, 0, 1, "0 or 1 argument ([port])")
+	return read_rune(env.input_port_arg(args, 0), false)
+}
+
+func (env *Env) peek_char(args ...Any) Any {
+	n_args("peek-char", args, 0, 1, "0 or 1 argument ([port])")
+	return read_rune(env.input_port_arg(args, 0), true)
+}
+
+func open_input_string(args ...Any) Any {
+	return NewInputPort("string", strings.NewReader(to_str(one_arg("open-input-string", args))))
+}
+
+func open_output_string(args ...Any) Any {
+	n_args("open-output-string", args, 0, 0, "no arguments")
+	return NewOutputPort("string", &strings.Builder{})
+}
+
+func get_output_string(args ...Any) Any {
+	p := to_output_port(one_arg("get-output-string", args))
+	b, ok := p.writer.(*strings.Builder)
+	if !ok {
+		panic("Not a string output port: " + LispyStr(p))
+	}
+	return Str{b.String()}
+}
+
+// (with-output-to-string thunk) -> everything written to current output port by thunk
+func (env *Env) with_output_to_string(args ...Any) Any {
+	f := to_function(one_arg("with-output-to-string", args))
+
+	root := env.root()
+	saved := root.ports.output
+	b := &strings.Builder{}
+	root.ports.output = NewOutputPort("string", b)
+	defer func() { root.ports.output = saved }()
+
+	f()
+	return Str{b.String()}
+}
+
+func (env *Env) current_input_port(args ...Any) Any {
+	n_args("current-input-port", args, 0, 0, "no arguments")
+	return env.CurrentInputPort()
+}
+
+func (env *Env) current_output_port(args ...Any) Any {
+	n_args("current-output-port", args, 0, 0, "no arguments")
+	return env.CurrentOutputPort()
+}
+
+func (env *Env) current_error_port(args ...Any) Any {
+	n_args("current-error-port", args, 0, 0, "no arguments")
+	return env.CurrentErrorPort()
+}
+
+func eof_object(args ...Any) Any {
+	return EofObject{}
+}
+
+func is_eof_object(args ...Any) Any {
+	_, ok := one_arg("eof-object?", args).(EofObject)
+	return Bool(ok)
+}
+
+// Tracing output goes to the current output port
+func (env *Env) trace(s string) {
+	env.CurrentOutputPort().write(s)
+}
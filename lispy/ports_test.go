@@ -0,0 +1,54 @@
+package lispy
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_ports(t *testing.T) {
+	in := strings.NewReader("line one\r\nλx")
+	out := &strings.Builder{}
+	errs := &strings.Builder{}
+	e := StdEnvWithIO(in, out, errs)
+
+	r := e.Eval(ParseStr(`(begin
+		(display "hi ")
+		(write "hi")
+		(newline)
+		(display (list "a" #\b 1/2))
+		(write-char #\! (current-output-port))
+		(write-string "?" (current-error-port))
+		(format t "~a" 1)
+		(list (read-line) (peek-char) (read-char) (read-line) (eof-object? (read-char))))`))
+
+	if LispyStr(r) != "'(\"line one\" #\\λ #\\λ \"x\" t)" {
+		t.Errorf("Unexpected input: %q", LispyStr(r))
+	}
+	if out.String() != "hi \"hi\"\n(a b 1/2)!1" {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+	if errs.String() != "?" {
+		t.Errorf("Unexpected error output: %q", errs.String())
+	}
+
+	examples := [][]string{
+		{"(define p (open-input-string \"ab\\ncd\"))", "#<input-port string>"},
+		{"(list (read-line p) (read-char p) (peek-char p) (read-line p) (read-line p))", "'(\"ab\" #\\c #\\d \"d\" #<eof>)"},
+		{"(with-output-to-string (lambda () (begin (display \"x\") (write #\\y))))", "\"x#\\\\y\""},
+		{"(begin (define o (open-output-string)) (write 'a o) (display \" z\" o) (format o \"~d\" 7) (get-output-string o))", "\"a z7\""},
+	}
+	run_eval_table(t, e, examples)
+	if out.String() != "hi \"hi\"\n(a b 1/2)!1" {
+		t.Errorf("with-output-to-string changed the output: %q", out.String())
+	}
+
+	// the REPL reads lines from the same port as read-line
+	p := NewInputPort("lines", strings.NewReader("(read-line)\r\nhello\n(+ 1 2)"))
+	lines := []string{}
+	for line, err := p.ReadLine(); err == nil; line, err = p.ReadLine() {
+		lines = append(lines, line)
+	}
+	if strings.Join(lines, "|") != "(read-line)|hello|(+ 1 2)" {
+		t.Errorf("Unexpected ReadLine() result: %q", lines)
+	}
+}
@@ -0,0 +1,178 @@
+package lispy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+// DisableProcesses makes run-process and run-pipeline unavailable for the interpreter,
+// e.g. for sandboxed scripts
+func (env *Env) DisableProcesses() {
+	env.root().no_processes = true
+}
+
+func (env *Env) check_processes(name string) {
+	if env.root().no_processes {
+		panic("'" + name + "' is disabled")
+	}
+}
+
+type process_options struct {
+	input   io.Reader
+	dir     string
+	env     []string
+	timeout time.Duration
+}
+
+// Parses trailing option pairs: 'input, 'dir, 'env, 'timeout
+func parse_process_options(name string, args []Any) process_options {
+	if len(args)%2 != 0 {
+		panic("Invalid '" + name + "' options, name and value pairs expected: " + LispyStr(List(args)))
+	}
+	opts := process_options{}
+	for i := 0; i < len(args); i += 2 {
+		v := args[i+1]
+		switch to_symbol(args[i]).Name {
+		case "input":
+			if p, ok := v.(*InputPort); ok {
+				opts.input = p.reader
+			} else {
+				opts.input = strings.NewReader(to_str(v))
+			}
+		case "dir":
+			opts.dir = to_str(v)
+		case "env":
+			opts.env = os.Environ()
+			for _, s := range to_list(v) {
+				opts.env = append(opts.env, to_str(s))
+			}
+		case "timeout":
+			seconds := to_float(real_arg(name, v)).Float64()
+			opts.timeout = time.Duration(seconds * float64(time.Second))
+		default:
+			panic("Invalid '" + name + "' option: " + LispyStr(args[i]))
+		}
+	}
+	return opts
+}
+
+const process_wait_delay = time.Second
+
+// Builds the command from the list of program name and arguments
+func make_command(ctx context.Context, name string, cmd List, opts process_options) *exec.Cmd {
+	if len(cmd) == 0 {
+		panic("Invalid '" + name + "' command: " + LispyStr(cmd))
+	}
+	argv := []string{}
+	for _, a := range cmd {
+		argv = append(argv, to_str(a))
+	}
+	c := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	c.Dir = opts.dir
+	c.Env = opts.env
+	// don't wait for output of orphaned children after the command is finished or killed
+	c.WaitDelay = process_wait_delay
+	return c
+}
+
+// Runs commands connecting stdout of each one to stdin of the next one,
+// returns list of exit code of the last command, its stdout and stderr of all commands
+func run_commands(name string, cmds []List, opts process_options) Any {
+	ctx := context.Background()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	procs := []*exec.Cmd{}
+	// ends of pipes between the commands, the parent closes them once the commands are started
+	pipes := []*os.File{}
+	defer func() {
+		for _, p := range pipes {
+			p.Close()
+		}
+	}()
+	input := opts.input
+	for i, cmd := range cmds {
+		c := make_command(ctx, name, cmd, opts)
+		c.Stdin = input
+		c.Stderr = &stderr
+		if i == len(cmds)-1 {
+			c.Stdout = &stdout
+		} else {
+			r, w, err := os.Pipe()
+			if err != nil {
+				panic(err)
+			}
+			pipes = append(pipes, r, w)
+			c.Stdout = w
+			input = r
+		}
+		procs = append(procs, c)
+	}
+
+	for i, c := range procs {
+		if err := c.Start(); err != nil {
+			// don't leave already started commands of the pipeline running
+			for _, started := range procs[:i] {
+				started.Process.Kill()
+				started.Wait()
+			}
+			panic(err)
+		}
+	}
+	for _, p := range pipes {
+		p.Close()
+	}
+	pipes = nil
+
+	// exit statuses are reported by the exit code, other errors are raised
+	var err error
+	for _, c := range procs {
+		var exit_err *exec.ExitError
+		if e := c.Wait(); e != nil && err == nil && !errors.As(e, &exit_err) {
+			err = e
+		}
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		panic("'" + name + "' timed out after " + opts.timeout.String())
+	}
+	if err != nil {
+		panic(err)
+	}
+	code := procs[len(procs)-1].ProcessState.ExitCode()
+	return List{ast.IntNum(int64(code)), Str{stdout.String()}, Str{stderr.String()}}
+}
+
+// (run-process program (args...) [option value]...) -> (exit-code stdout stderr)
+func (env *Env) run_process(args ...Any) Any {
+	env.check_processes("run-process")
+	n_args("run-process", args, 2, -1, "at least 2 arguments (program args [option value]...)")
+	cmd := append(List{args[0]}, to_list(args[1])...)
+	return run_commands("run-process", []List{cmd}, parse_process_options("run-process", args[2:]))
+}
+
+// (run-pipeline ((program args...)...) [option value]...) -> (exit-code stdout stderr)
+func (env *Env) run_pipeline(args ...Any) Any {
+	env.check_processes("run-pipeline")
+	n_args("run-pipeline", args, 1, -1, "at least 1 argument (commands [option value]...)")
+	cmds := []List{}
+	for _, c := range to_list(args[0]) {
+		cmds = append(cmds, to_list(c))
+	}
+	if len(cmds) == 0 {
+		panic("Invalid 'run-pipeline' argument, no commands: " + LispyStr(args[0]))
+	}
+	return run_commands("run-pipeline", cmds, parse_process_options("run-pipeline", args[1:]))
+}
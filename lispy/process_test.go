@@ -0,0 +1,60 @@
+package lispy
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_processes(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh available")
+	}
+	dir := t.TempDir()
+	e := StdEnv()
+	e.Define("dir", Str{dir})
+
+	examples := [][]string{
+		{"(run-process \"sh\" (list \"-c\" \"echo $FOO; echo err >&2; exit 3\") 'env (list \"FOO=bar\"))", "'(3 \"bar\\n\" \"err\\n\")"},
+		{"(run-process \"sh\" (list \"-c\" \"pwd\") 'dir dir)", "'(0 \"" + dir + "\\n\" \"\")"},
+		{"(run-process \"sh\" (list \"-c\" \"cat\") 'input \"text\")", "'(0 \"text\" \"\")"},
+		{"(run-process \"sh\" (list \"-c\" \"cat\") 'input (open-input-string \"port\"))", "'(0 \"port\" \"\")"},
+		{"(run-pipeline (list (list \"sh\" \"-c\" \"echo b; echo a; echo c\") (list \"sort\") (list \"head\" \"-n\" \"2\")))", "'(0 \"a\\nb\\n\" \"\")"},
+	}
+	run_eval_table(t, e, examples)
+
+	run_error_table(t, e, map[string]string{"(run-process \"sleep\" (list \"5\") 'timeout 0.1)": "timed out"})
+
+	// orphaned child holding stdout doesn't block after the timeout
+	start := time.Now()
+	run_error_table(t, e, map[string]string{"(run-process \"sh\" (list \"-c\" \"sleep 5 & sleep 5\") 'timeout 0.1)": "timed out"})
+	if d := time.Since(start); d > 3*time.Second {
+		t.Errorf("Timed out command is waited for %s", d)
+	}
+
+	// pipes are closed when the pipeline fails to start
+	if fds, err := os.ReadDir("/proc/self/fd"); err == nil {
+		run_error_table(t, e, map[string]string{
+			"(run-pipeline (list (list \"/nonexistent/command\") (list \"true\") (list \"true\")))": "no such file",
+		})
+		if after, _ := os.ReadDir("/proc/self/fd"); len(after) != len(fds) {
+			t.Errorf("Leaked file descriptors: %d before, %d after", len(fds), len(after))
+		}
+	}
+
+	// started commands are killed when the next one fails to start
+	run_error_table(t, e, map[string]string{
+		"(run-pipeline (list (list \"sh\" \"-c\" \"sleep 0.2; touch leaked\") (list \"/nonexistent/command\")) 'dir dir)": "no such file",
+	})
+	time.Sleep(400 * time.Millisecond)
+	if _, err := os.Stat(filepath.Join(dir, "leaked")); err == nil {
+		t.Errorf("Started command of the pipeline is not killed")
+	}
+	e.DisableProcesses()
+	run_error_table(t, e, map[string]string{
+		"(run-process \"sh\" (list \"-c\" \"true\"))": "disabled",
+		"(run-pipeline (list (list \"true\")))":       "disabled",
+	})
+}
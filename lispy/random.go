@@ -0,0 +1,108 @@
+package lispy
+
+import (
+	"math/big"
+	"math/rand"
+	"time"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+// Seed resets the pseudo-random generator of the interpreter,
+// the same seed gives the same sequence of random values
+func (env *Env) Seed(seed int64) {
+	env.root().rng = rand.New(rand.NewSource(seed))
+}
+
+// Rand returns the pseudo-random generator of the interpreter
+func (env *Env) Rand() *rand.Rand {
+	root := env.root()
+	if root.rng == nil {
+		root.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return root.rng
+}
+
+func (env *Env) root() *Env {
+	for env.parent != nil {
+		env = env.parent
+	}
+	return env
+}
+
+// (random-seed! n)
+func (env *Env) random_seed(args ...Any) Any {
+	seed := to_int(one_arg("random-seed!", args)).Value
+	// bignum seed is folded to int64
+	env.Seed(new(big.Int).Mod(seed, new(big.Int).Lsh(big.NewInt(1), 64)).Int64())
+	return nil
+}
+
+// (random-integer n) -> [0, n), (random-integer a b) -> [a, b), bounds could be bignums
+func (env *Env) random_integer(args ...Any) Any {
+	lo := big.NewInt(0)
+	var hi *big.Int
+	switch len(args) {
+	case 1:
+		hi = to_int(args[0]).Value
+	case 2:
+		lo = to_int(args[0]).Value
+		hi = to_int(args[1]).Value
+	default:
+		panic("'random-integer' requires 1 or 2 arguments ([low] high), provided: " + LispyStr(args))
+	}
+
+	n := new(big.Int).Sub(hi, lo)
+	if n.Sign() <= 0 {
+		panic("Empty 'random-integer' range: " + LispyStr(args))
+	}
+
+	r := new(big.Int).Rand(env.Rand(), n)
+	return Int{r.Add(r, lo)}
+}
+
+// (random-real) -> [0, 1)
+func (env *Env) random_real(args ...Any) Any {
+	if len(args) != 0 {
+		panic("'random-real' requires no arguments, provided: " + LispyStr(args))
+	}
+	return ast.FloatNum(env.Rand().Float64())
+}
+
+// (random-normal [mean [stddev]]), standard normal distribution by default
+func (env *Env) random_normal(args ...Any) Any {
+	if len(args) > 2 {
+		panic("'random-normal' requires up to 2 arguments ([mean [stddev]]), provided: " + LispyStr(args))
+	}
+	mean := to_float(get(args, 0, ast.IntNum(0))).Float64()
+	stddev := to_float(get(args, 1, ast.IntNum(1))).Float64()
+	return ast.FloatNum(env.Rand().NormFloat64()*stddev + mean)
+}
+
+// (random-exponential [rate]), rate is 1 by default
+func (env *Env) random_exponential(args ...Any) Any {
+	if len(args) > 1 {
+		panic("'random-exponential' requires up to 1 argument ([rate]), provided: " + LispyStr(args))
+	}
+	rate := to_float(get(args, 0, ast.IntNum(1))).Float64()
+	if rate <= 0 {
+		panic("Invalid 'random-exponential' rate: " + LispyStr(args[0]))
+	}
+	return ast.FloatNum(env.Rand().ExpFloat64() / rate)
+}
+
+// (random-choice list) -> random element of the list
+func (env *Env) random_choice(args ...Any) Any {
+	l := to_list(one_arg("random-choice", args))
+	if len(l) == 0 {
+		panic("'random-choice' from empty list")
+	}
+	return l[env.Rand().Intn(len(l))]
+}
+
+// (shuffle list) -> new list with elements in random order
+func (env *Env) shuffle(args ...Any) Any {
+	r := append(List{}, to_list(one_arg("shuffle", args))...)
+	env.Rand().Shuffle(len(r), func(i, j int) { r[i], r[j] = r[j], r[i] })
+	return r
+}
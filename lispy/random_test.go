@@ -0,0 +1,43 @@
+package lispy
+
+import (
+	"testing"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+func Test_random(t *testing.T) {
+	expr := ParseStr(`(list
+		(random-integer 10)
+		(random-integer -5 5)
+		(random-integer 100000000000000000000000000000000000000)
+		(random-real)
+		(random-normal 10 2)
+		(random-exponential 0.5)
+		(random-choice '(a b c))
+		(shuffle '(1 2 3 4 5)))`)
+
+	e1 := StdEnv()
+	e1.Seed(42)
+	r1 := LispyStr(e1.Eval(expr))
+
+	e2 := StdEnv()
+	e2.Eval(ParseStr("(random-seed! 42)"))
+	r2 := LispyStr(e2.Eval(expr))
+
+	if r1 != r2 {
+		t.Errorf("Same seed gives different values: %q and %q", r1, r2)
+	}
+
+	for i := 0; i < 100; i++ {
+		n := e1.Eval(ParseStr("(random-integer 3 5)"))
+		if !equal(n, ast.IntNum(3)) && !equal(n, ast.IntNum(4)) {
+			t.Fatalf("Random integer out of range: %q", LispyStr(n))
+		}
+	}
+
+	sorted := e1.Eval(ParseStr("(apply + (shuffle '(1 2 3 4 5)))"))
+	if LispyStr(sorted) != "15" {
+		t.Errorf("Shuffle lost elements: %q", LispyStr(sorted))
+	}
+}
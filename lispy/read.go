@@ -0,0 +1,266 @@
+package lispy
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+func (env *Env) String() string {
+	return "#<environment>"
+}
+
+func to_env(e Any) *Env {
+	if v, ok := e.(*Env); ok {
+		return v
+	}
+	panic("Invalid environment: " + LispyStr(e))
+}
+
+// Converts parsed expression into plain data: special forms become ordinary lists
+func to_data(expr Any) Any {
+	switch v := expr.(type) {
+	case List:
+		lst := List{}
+		for _, item := range v {
+			lst = append(lst, to_data(item))
+		}
+		return lst
+	case ast.Quote:
+		return List{Symbol{"quote"}, to_data(v.Value)}
+	case ast.Unquote:
+		return List{Symbol{"unquote"}, to_data(v.Value)}
+	case ast.If:
+		return List{Symbol{"if"}, to_data(v.Test), to_data(v.PosBranch), to_data(v.NegBranch)}
+	case ast.Define:
+		return List{Symbol{"define"}, v.Sym, to_data(v.Value)}
+	case ast.Set:
+		return List{Symbol{"set!"}, v.Sym, to_data(v.Value)}
+	case ast.Lambda:
+		args := List{}
+		for _, s := range v.Args {
+			args = append(args, s)
+		}
+		return List{Symbol{"lambda"}, args, to_data(v.Body)}
+	default:
+		return v
+	}
+}
+
+func check_form(lst List, name string, size int) {
+	if len(lst) != size {
+		panic("Invalid '" + name + "' expression: " + LispyStr(lst))
+	}
+}
+
+// Symbol defined by define or set! form
+func form_symbol(lst List, name string) Symbol {
+	check_form(lst, name, 3)
+	s, ok := lst[1].(Symbol)
+	if !ok {
+		panic("Invalid '" + name + "' expression: " + LispyStr(lst))
+	}
+	return s
+}
+
+// Converts plain data into expression: lists headed by special form names become AST nodes
+func from_data(data Any) Any {
+	lst, ok := data.(List)
+	if !ok || len(lst) == 0 {
+		return data
+	}
+	head, _ := lst[0].(Symbol)
+	switch head.Name {
+	case "quote":
+		check_form(lst, "quote", 2)
+		return ast.Quote{Value: from_quoted_data(lst[1])}
+	case "if":
+		check_form(lst, "if", 4)
+		return ast.If{Test: from_data(lst[1]), PosBranch: from_data(lst[2]), NegBranch: from_data(lst[3])}
+	case "define":
+		return ast.Define{Sym: form_symbol(lst, "define"), Value: from_data(lst[2])}
+	case "set!":
+		return ast.Set{Sym: form_symbol(lst, "set!"), Value: from_data(lst[2])}
+	case "lambda":
+		check_form(lst, "lambda", 3)
+		args := []Symbol{}
+		for _, a := range to_list(lst[1]) {
+			s, ok := a.(Symbol)
+			if !ok {
+				panic("Invalid 'lambda' argument: " + LispyStr(a))
+			}
+			args = append(args, s)
+		}
+		return ast.Lambda{Args: args, Body: from_data(lst[2])}
+	}
+	r := List{}
+	for _, item := range lst {
+		r = append(r, from_data(item))
+	}
+	return r
+}
+
+// Inside of quote only unquoted parts are evaluated
+func from_quoted_data(data Any) Any {
+	lst, ok := data.(List)
+	if !ok || len(lst) == 0 {
+		return data
+	}
+	if head, ok := lst[0].(Symbol); ok && head.Name == "unquote" {
+		check_form(lst, "unquote", 2)
+		return ast.Unquote{Value: from_data(lst[1])}
+	}
+	r := List{}
+	for _, item := range lst {
+		r = append(r, from_quoted_data(item))
+	}
+	return r
+}
+
+// Reads the text of the next datum from r, returns false at the end of input
+func read_datum(r *bufio.Reader) (string, bool) {
+	var b strings.Builder
+	depth := 0
+	// datum is complete if there is something besides quote prefixes
+	complete := func() bool {
+		return depth == 0 && strings.TrimLeft(b.String(), "',") != ""
+	}
+	for {
+		c, _, err := r.ReadRune()
+		if err == io.EOF {
+			if depth > 0 {
+				panic("Unexpected end of input: " + b.String())
+			}
+			return b.String(), complete()
+		} else if err != nil {
+			panic(err)
+		}
+
+		switch {
+		case c == ';':
+			if _, err := r.ReadString('\n'); err != nil && err != io.EOF {
+				panic(err)
+			}
+			if complete() {
+				return b.String(), true
+			}
+			if b.Len() > 0 {
+				// comment separates tokens like a newline
+				b.WriteRune('\n')
+			}
+		case unicode.IsSpace(c):
+			if complete() {
+				return b.String(), true
+			}
+			if b.Len() > 0 {
+				b.WriteRune(c)
+			}
+		case c == '(':
+			if complete() {
+				r.UnreadRune()
+				return b.String(), true
+			}
+			depth++
+			b.WriteRune(c)
+		case c == ')':
+			if depth == 0 {
+				if complete() {
+					r.UnreadRune()
+					return b.String(), true
+				}
+				panic("Unexpected ')'")
+			}
+			depth--
+			b.WriteRune(c)
+			if depth == 0 {
+				return b.String(), true
+			}
+		case c == '"':
+			b.WriteRune(c)
+			read_string_literal(r, &b)
+			if depth == 0 {
+				return b.String(), true
+			}
+		case c == '#':
+			b.WriteRune(c)
+			// character literal could be followed by any character: #\( or #\space
+			if next, _, err := r.ReadRune(); err == nil {
+				b.WriteRune(next)
+				if next == '\\' {
+					if ch, _, err := r.ReadRune(); err == nil {
+						b.WriteRune(ch)
+					}
+				}
+			}
+		default:
+			b.WriteRune(c)
+		}
+	}
+}
+
+func read_string_literal(r *bufio.Reader, b *strings.Builder) {
+	escaped := false
+	for {
+		c, _, err := r.ReadRune()
+		if err != nil {
+			panic("Unexpected end of input in string: " + b.String())
+		}
+		b.WriteRune(c)
+		if escaped {
+			escaped = false
+		} else if c == '\\' {
+			escaped = true
+		} else if c == '"' {
+			return
+		}
+	}
+}
+
+func read_from(r *bufio.Reader) Any {
+	text, ok := read_datum(r)
+	if !ok {
+		return EofObject{}
+	}
+	seq := ParseStr(text)
+	if len(seq) != 1 {
+		panic("Invalid datum: " + text)
+	}
+	return to_data(seq[0])
+}
+
+// (read [port]) -> next datum from port or eof-object
+func (env *Env) read(args ...Any) Any {
+	n_args("read", args, 0, 1, "0 or 1 argument ([port])")
+	return read_from(env.input_port_arg(args, 0).reader)
+}
+
+// (read-from-string s) -> first datum of string
+func read_from_string(args ...Any) Any {
+	s := to_str(one_arg("read-from-string", args))
+	return read_from(bufio.NewReader(strings.NewReader(s)))
+}
+
+// (eval expr [env]) - by default evaluates in the global environment
+func (env *Env) eval(args ...Any) Any {
+	n_args("eval", args, 1, 2, "1 or 2 arguments (expr [env])")
+	e := env.root()
+	if len(args) > 1 {
+		e = to_env(args[1])
+	}
+	return e.eval_expr(from_data(args[0]))
+}
+
+func (env *Env) interaction_environment(args ...Any) Any {
+	n_args("interaction-environment", args, 0, 0, "no arguments")
+	return env.root()
+}
+
+// (make-environment) -> new environment on top of the global one,
+// definitions made in it are not visible outside
+func (env *Env) make_environment(args ...Any) Any {
+	n_args("make-environment", args, 0, 0, "no arguments")
+	return newEnv(env.root())
+}
@@ -0,0 +1,50 @@
+package lispy
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_read_eval(t *testing.T) {
+	e := StdEnvWithIO(strings.NewReader("(define x 1) ; comment\n'(a \"b)\" #\\)) 42"), &strings.Builder{}, &strings.Builder{})
+
+	examples := [][]string{
+		{"(read)", "'(define x 1)"},
+		{"(read)", "'(quote (a \"b)\" #\\)))"},
+		{"(list (read) (eof-object? (read)))", "'(42 t)"},
+		{"(read-from-string \"(lambda (x) (if x 'a ,x)) rest\")", "'(lambda (x) (if x (quote a) (unquote x)))"},
+		{"(eof-object? (read-from-string \"  ; only comment\"))", "t"},
+		{"(read-from-string \"(a;c\nb)\")", "'(a b)"},
+		{"(car (read-from-string \"(if 1 2 3)\"))", "if"},
+		{"(begin (eval (read-from-string \"(define sq (lambda (x) (* x x)))\")) 'ok)", "ok"},
+		{"(eval (list 'sq 5))", "25"},
+		{"(eval (read-from-string \"'(1 ,(+ 1 1))\"))", "'(1 2)"},
+		// decimals are printed without #d prefix and read back as floats
+		{"(list (number->string #d12.50) (read-from-string (number->string #d12.50)) (decimal-scale (read-from-string \"#d12.50\")))", "'(\"12.50\" 12.5 2)"},
+		{"(define env (make-environment))", "#<environment>"},
+		{"(eval '(define hidden 7) env)", "7"},
+		{"(list (eval 'hidden env) (eval '(sq 3) (interaction-environment)))", "'(7 9)"},
+	}
+	run_eval_table(t, e, examples)
+
+	// read returns data that is printed back to the same text
+	for _, src := range []string{
+		"(define f (lambda (a b) (if (> a b) (set! a b) (quote done))))",
+		"(1 2/3 -7 \"s\" #\\a nil t false ())",
+	} {
+		d := read_from_string(Str{src})
+		if LispyStr(d) != src {
+			t.Errorf("Not round-tripped: %q -> %q", src, LispyStr(d))
+		}
+		if LispyStr(read_from_string(Str{LispyStr(d)})) != src {
+			t.Errorf("Not round-tripped twice: %q", src)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected hidden to be undefined in the global environment")
+		}
+	}()
+	e.Eval(ParseStr("hidden"))
+}
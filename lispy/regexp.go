@@ -0,0 +1,148 @@
+package lispy
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+// Compiled regular expression as lispy value
+type Regexp struct {
+	*regexp.Regexp
+}
+
+func (this Regexp) String() string {
+	return "#<regexp " + strconv.Quote(this.Regexp.String()) + ">"
+}
+
+// Pattern string is compiled on the fly
+func to_regexp(name string, r Any) Regexp {
+	switch v := r.(type) {
+	case Regexp:
+		return v
+	case Str:
+		return compile_regexp(v.Value)
+	default:
+		panic("Invalid '" + name + "' regexp: " + LispyStr(r))
+	}
+}
+
+func compile_regexp(pattern string) Regexp {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return Regexp{re}
+}
+
+func regexp_compile(args ...Any) Any {
+	return compile_regexp(to_str(one_arg("regexp-compile", args)))
+}
+
+// Converts byte offsets in s into rune offsets,
+// runes are counted from the previous offset, so increasing offsets of all matches take linear time
+type rune_offsets struct {
+	s     string
+	bytes int
+	runes int
+}
+
+func (this *rune_offsets) at(b int) int {
+	if b >= this.bytes {
+		this.runes += utf8.RuneCountInString(this.s[this.bytes:b])
+	} else {
+		// submatch could start before the end of the previous one
+		this.runes -= utf8.RuneCountInString(this.s[b:this.bytes])
+	}
+	this.bytes = b
+	return this.runes
+}
+
+// Submatches as list of (text start end) with positions in runes,
+// group that didn't participate in the match is nil
+func match_to_list(offsets *rune_offsets, loc []int) List {
+	r := List{}
+	for i := 0; i < len(loc); i += 2 {
+		if loc[i] < 0 {
+			r = append(r, nil)
+			continue
+		}
+		start := offsets.at(loc[i])
+		end := offsets.at(loc[i+1])
+		r = append(r, List{Str{offsets.s[loc[i]:loc[i+1]]}, ast.IntNum(int64(start)), ast.IntNum(int64(end))})
+	}
+	return r
+}
+
+// (regexp-match re s) -> ((text start end) submatches...) or false
+func regexp_match(args ...Any) Any {
+	n_args("regexp-match", args, 2, 2, "exactly 2 arguments (regexp string)")
+	re := to_regexp("regexp-match", args[0])
+	s := to_str(args[1])
+	loc := re.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return Bool(false)
+	}
+	return match_to_list(&rune_offsets{s: s}, loc)
+}
+
+// (regexp-match-all re s) -> list of matches, each as returned by regexp-match
+func regexp_match_all(args ...Any) Any {
+	n_args("regexp-match-all", args, 2, 2, "exactly 2 arguments (regexp string)")
+	re := to_regexp("regexp-match-all", args[0])
+	s := to_str(args[1])
+	r := List{}
+	offsets := &rune_offsets{s: s}
+	for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+		r = append(r, match_to_list(offsets, loc))
+	}
+	return r
+}
+
+// (regexp-replace re s replacement) replaces all matches,
+// replacement is either a template string with $1 references
+// or a function that takes the match and submatches as strings and returns a string,
+// submatch that didn't participate in the match is nil
+func regexp_replace(args ...Any) Any {
+	n_args("regexp-replace", args, 3, 3, "exactly 3 arguments (regexp string replacement)")
+	re := to_regexp("regexp-replace", args[0])
+	s := to_str(args[1])
+
+	if tmpl, ok := args[2].(Str); ok {
+		return Str{re.ReplaceAllString(s, tmpl.Value)}
+	}
+
+	f := to_function(args[2])
+	b := strings.Builder{}
+	last := 0
+	// submatches are taken from the original string, so context-dependent patterns work
+	for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+		f_args := []Any{}
+		for i := 0; i < len(loc); i += 2 {
+			if loc[i] < 0 {
+				f_args = append(f_args, nil)
+			} else {
+				f_args = append(f_args, Str{s[loc[i]:loc[i+1]]})
+			}
+		}
+		b.WriteString(s[last:loc[0]])
+		b.WriteString(to_str(f(f_args...)))
+		last = loc[1]
+	}
+	b.WriteString(s[last:])
+	return Str{b.String()}
+}
+
+// (regexp-split re s) -> list of strings between matches
+func regexp_split(args ...Any) Any {
+	n_args("regexp-split", args, 2, 2, "exactly 2 arguments (regexp string)")
+	re := to_regexp("regexp-split", args[0])
+	r := List{}
+	for _, part := range re.Split(to_str(args[1]), -1) {
+		r = append(r, Str{part})
+	}
+	return r
+}
@@ -0,0 +1,18 @@
+package lispy
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_regexp_long_string(t *testing.T) {
+	n := 100000
+	s := Str{strings.Repeat("жx", n)}
+	matches := regexp_match_all(Str{"x"}, s).(List)
+	if len(matches) != n {
+		t.Fatalf("Unexpected number of matches: %d", len(matches))
+	}
+	if r := LispyStr(matches[n-1]); r != "((\"x\" 199999 200000))" {
+		t.Errorf("Unexpected last match: %s", r)
+	}
+}
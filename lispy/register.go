@@ -0,0 +1,98 @@
+package lispy
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var error_type = reflect.TypeOf((*error)(nil)).Elem()
+
+// RegisterFunc defines name as a builtin that calls Go function fn,
+// e.g. func(string, int) (bool, error).
+// Arguments are converted by FromValue, except of Any parameters that get lispy values as is,
+// results are converted by ToValue, so pointers to structs give alists
+// unlike methods of Go objects that keep such results opaque (see NewGoObject),
+// several results are returned as a list, non-nil error result is raised as an error.
+// Panics if fn is not a function.
+func (env *Env) RegisterFunc(name string, fn any) {
+	if fn == nil {
+		panic("RegisterFunc: not a function: nil")
+	}
+	f := reflect.ValueOf(fn)
+	if f.Kind() != reflect.Func || f.IsNil() {
+		panic("RegisterFunc: not a function: " + f.Type().String())
+	}
+	env.named_objects[name] = go_function(name, f, func(v reflect.Value) Any { return ToValue(v.Interface()) })
+}
+
+func go_function_usage(t reflect.Type) string {
+	types := []string{}
+	for i := 0; i < t.NumIn(); i++ {
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			types = append(types, t.In(i).Elem().String()+"...")
+		} else {
+			types = append(types, t.In(i).String())
+		}
+	}
+	n := strconv.Itoa(t.NumIn())
+	if t.IsVariadic() {
+		n = "at least " + strconv.Itoa(t.NumIn()-1)
+	} else {
+		n = "exactly " + n
+	}
+	return n + " arguments (" + strings.Join(types, " ") + ")"
+}
+
+// Results are converted by result function
+func go_function(name string, f reflect.Value, result func(reflect.Value) Any) PureFunction {
+	t := f.Type()
+	min, max := t.NumIn(), t.NumIn()
+	if t.IsVariadic() {
+		min, max = t.NumIn()-1, -1
+	}
+	usage := go_function_usage(t)
+
+	return func(args ...Any) Any {
+		n_args(name, args, min, max, usage)
+		in := []reflect.Value{}
+		for i, arg := range args {
+			var at reflect.Type
+			if t.IsVariadic() && i >= t.NumIn()-1 {
+				at = t.In(t.NumIn() - 1).Elem()
+			} else {
+				at = t.In(i)
+			}
+			v := reflect.New(at).Elem()
+			if at.Kind() == reflect.Interface && at.NumMethod() == 0 {
+				// Any parameter gets lispy value as is
+				if arg != nil {
+					v.Set(reflect.ValueOf(arg))
+				}
+			} else if err := from_value(arg, v); err != nil {
+				panic("Invalid '" + name + "' argument " + strconv.Itoa(i+1) + ": " + err.Error())
+			}
+			in = append(in, v)
+		}
+
+		out := f.Call(in)
+		if n := len(out); n > 0 && t.Out(n-1) == error_type {
+			if err := out[n-1]; !err.IsNil() {
+				panic(err.Interface().(error))
+			}
+			out = out[:n-1]
+		}
+		switch len(out) {
+		case 0:
+			return nil
+		case 1:
+			return result(out[0])
+		default:
+			r := List{}
+			for _, v := range out {
+				r = append(r, result(v))
+			}
+			return r
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package lispy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func Test_RegisterFunc(t *testing.T) {
+	e := StdEnv()
+	e.RegisterFunc("repeat", func(s string, n int) (string, error) {
+		if n < 0 {
+			return "", errors.New("negative count")
+		}
+		return strings.Repeat(s, n), nil
+	})
+	e.RegisterFunc("divmod", func(a, b int64) (int64, int64) { return a / b, a % b })
+	e.RegisterFunc("join", func(sep string, parts ...string) string { return strings.Join(parts, sep) })
+	e.RegisterFunc("origin", func(p *test_point) test_point { return test_point{-p.X, -p.Y} })
+	e.RegisterFunc("apply-twice", func(f PureFunction, x Any) Any { return f(f(x)) })
+	e.RegisterFunc("nothing", func() {})
+	e.RegisterFunc("new-point", func(x, y int) *test_point { return &test_point{x, y} })
+
+	examples := [][]string{
+		{"(repeat \"ab\" 3)", "\"ababab\""},
+		{"(divmod 17 5)", "'(3 2)"},
+		{"(join \", \" \"a\" \"b\" \"c\")", "\"a, b, c\""},
+		{"(join \"-\")", "\"\""},
+		{"(origin '((X 1) (Y 2)))", "'((X -1) (Y -2))"},
+		{"(apply-twice (lambda (x) (* x 10)) 3)", "300"},
+		{"(nothing)", "nil"},
+		{"(new-point 1 2)", "'((X 1) (Y 2))"},
+	}
+	run_eval_table(t, e, examples)
+
+	errs := map[string]string{
+		"(repeat \"a\" -1)": "negative count",
+		"(repeat \"a\")":    "'repeat' requires exactly 2 arguments (string int)",
+		"(repeat 1 2)":      "Invalid 'repeat' argument 1",
+		"(divmod 1 2 3)":    "requires exactly 2 arguments",
+		"(join)":            "requires at least 1 arguments",
+	}
+	run_error_table(t, e, errs)
+}
+
+func Test_RegisterFunc_invalid(t *testing.T) {
+	var nil_func func()
+	for _, fn := range []any{nil, 1, nil_func} {
+		func() {
+			defer func() {
+				r := recover()
+				if r == nil || !strings.Contains(fmt.Sprint(r), "RegisterFunc: not a function") {
+					t.Errorf("Expected error for %#v, got: %v", fn, r)
+				}
+			}()
+			StdEnv().RegisterFunc("f", fn)
+		}()
+	}
+}
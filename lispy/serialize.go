@@ -0,0 +1,408 @@
+package lispy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"regexp"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+// Binary format: version byte followed by the value.
+// Each value is a tag byte followed by the payload,
+// lengths and counts are uvarints, big integers are sign byte and magnitude bytes.
+const binary_version = 1
+
+// Lists nested deeper are rejected by both encoder and decoder,
+// so malformed data can't exhaust the stack
+const max_binary_depth = 10000
+
+const (
+	tag_nil byte = iota
+	tag_ast_nil
+	tag_false
+	tag_true
+	tag_int
+	tag_rat
+	tag_decimal
+	tag_float
+	tag_special_float
+	tag_exact_complex
+	tag_complex
+	tag_char
+	tag_str
+	tag_symbol
+	tag_list
+	tag_time
+	tag_duration
+	tag_regexp
+)
+
+type binary_encoder struct {
+	buf   bytes.Buffer
+	depth int
+}
+
+func (e *binary_encoder) uvarint(n uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	e.buf.Write(tmp[:binary.PutUvarint(tmp[:], n)])
+}
+
+func (e *binary_encoder) varint(n int64) {
+	var tmp [binary.MaxVarintLen64]byte
+	e.buf.Write(tmp[:binary.PutVarint(tmp[:], n)])
+}
+
+func (e *binary_encoder) bytes(b []byte) {
+	e.uvarint(uint64(len(b)))
+	e.buf.Write(b)
+}
+
+func (e *binary_encoder) big_int(i *big.Int) {
+	if i.Sign() < 0 {
+		e.buf.WriteByte(1)
+	} else {
+		e.buf.WriteByte(0)
+	}
+	e.bytes(i.Bytes())
+}
+
+func (e *binary_encoder) rat(r *big.Rat) {
+	e.big_int(r.Num())
+	e.big_int(r.Denom())
+}
+
+func (e *binary_encoder) float64(f float64) {
+	var tmp [8]byte
+	binary.BigEndian.PutUint64(tmp[:], math.Float64bits(f))
+	e.buf.Write(tmp[:])
+}
+
+func (e *binary_encoder) value(v Any) error {
+	switch x := v.(type) {
+	case nil:
+		e.buf.WriteByte(tag_nil)
+	case ast.Nil:
+		e.buf.WriteByte(tag_ast_nil)
+	case Bool:
+		if x {
+			e.buf.WriteByte(tag_true)
+		} else {
+			e.buf.WriteByte(tag_false)
+		}
+	case Int:
+		e.buf.WriteByte(tag_int)
+		e.big_int(x.Value)
+	case Rat:
+		e.buf.WriteByte(tag_rat)
+		e.rat(x.Value)
+	case Decimal:
+		if x.Scale > max_decimal_scale {
+			return fmt.Errorf("Not serializable, decimal scale is greater than %d: %d", max_decimal_scale, x.Scale)
+		}
+		e.buf.WriteByte(tag_decimal)
+		e.big_int(x.Unscaled)
+		e.varint(int64(x.Scale))
+	case Float:
+		if x.IsSpecial() {
+			e.buf.WriteByte(tag_special_float)
+			e.float64(x.Special)
+		} else {
+			e.buf.WriteByte(tag_float)
+			e.rat(x.Value)
+		}
+	case ExactComplex:
+		e.buf.WriteByte(tag_exact_complex)
+		e.rat(x.Re)
+		e.rat(x.Im)
+	case Complex:
+		e.buf.WriteByte(tag_complex)
+		e.float64(real(x.Value))
+		e.float64(imag(x.Value))
+	case Char:
+		e.buf.WriteByte(tag_char)
+		e.uvarint(uint64(x))
+	case Str:
+		e.buf.WriteByte(tag_str)
+		e.bytes([]byte(x.Value))
+	case Symbol:
+		e.buf.WriteByte(tag_symbol)
+		e.bytes([]byte(x.Name))
+	case List:
+		if e.depth >= max_binary_depth {
+			return too_deep_binary
+		}
+		e.buf.WriteByte(tag_list)
+		e.uvarint(uint64(len(x)))
+		e.depth++
+		for _, item := range x {
+			if err := e.value(item); err != nil {
+				return err
+			}
+		}
+		e.depth--
+	case Time:
+		b, err := x.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		e.buf.WriteByte(tag_time)
+		e.bytes(b)
+	case Duration:
+		e.buf.WriteByte(tag_duration)
+		e.varint(int64(x.Duration))
+	case Regexp:
+		e.buf.WriteByte(tag_regexp)
+		e.bytes([]byte(x.Regexp.String()))
+	case ast.Quote, ast.Unquote, ast.If, ast.Define, ast.Set, ast.Lambda:
+		// code in quoted data is stored as plain lists
+		return e.value(to_data(x))
+	default:
+		return fmt.Errorf("Not serializable: %s", LispyStr(v))
+	}
+	return nil
+}
+
+// MarshalBinary encodes lispy data into compact binary format,
+// functions, ports and other host objects are not serializable
+func MarshalBinary(v Any) ([]byte, error) {
+	e := binary_encoder{}
+	e.buf.WriteByte(binary_version)
+	if err := e.value(v); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+type binary_reader interface {
+	io.Reader
+	io.ByteReader
+}
+
+type binary_decoder struct {
+	r     binary_reader
+	depth int
+}
+
+var invalid_binary = errors.New("Invalid binary data")
+var too_deep_binary = fmt.Errorf("%w: lists are nested deeper than %d", invalid_binary, max_binary_depth)
+
+func (d *binary_decoder) byte() byte {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		panic(invalid_binary)
+	}
+	return b
+}
+
+func (d *binary_decoder) uvarint() uint64 {
+	n, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		panic(invalid_binary)
+	}
+	return n
+}
+
+func (d *binary_decoder) varint() int64 {
+	n, err := binary.ReadVarint(d.r)
+	if err != nil {
+		panic(invalid_binary)
+	}
+	return n
+}
+
+func (d *binary_decoder) bytes() []byte {
+	n := d.uvarint()
+	if n > math.MaxInt64 {
+		panic(invalid_binary)
+	}
+	// buffer grows with the data actually read, not with the declared length
+	var b bytes.Buffer
+	if _, err := io.CopyN(&b, d.r, int64(n)); err != nil {
+		panic(invalid_binary)
+	}
+	return b.Bytes()
+}
+
+// Canonical form only: sign byte 0 or 1, no leading zero bytes, no negative zero
+func (d *binary_decoder) big_int() *big.Int {
+	neg := d.byte()
+	b := d.bytes()
+	if neg > 1 || (len(b) > 0 && b[0] == 0) || (neg == 1 && len(b) == 0) {
+		panic(invalid_binary)
+	}
+	i := new(big.Int).SetBytes(b)
+	if neg == 1 {
+		i.Neg(i)
+	}
+	return i
+}
+
+func (d *binary_decoder) rat() *big.Rat {
+	num := d.big_int()
+	denom := d.big_int()
+	if denom.Sign() <= 0 {
+		panic(invalid_binary)
+	}
+	return new(big.Rat).SetFrac(num, denom)
+}
+
+func (d *binary_decoder) float64() float64 {
+	var tmp [8]byte
+	if _, err := io.ReadFull(d.r, tmp[:]); err != nil {
+		panic(invalid_binary)
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(tmp[:]))
+}
+
+func (d *binary_decoder) value() Any {
+	switch d.byte() {
+	case tag_nil:
+		return nil
+	case tag_ast_nil:
+		return ast.Nil{}
+	case tag_false:
+		return Bool(false)
+	case tag_true:
+		return Bool(true)
+	case tag_int:
+		return Int{d.big_int()}
+	case tag_rat:
+		r := d.rat()
+		if r.IsInt() {
+			// integers are encoded as tag_int
+			panic(invalid_binary)
+		}
+		return Rat{r}
+	case tag_decimal:
+		unscaled := d.big_int()
+		scale := d.varint()
+		if scale < 0 || scale > max_decimal_scale {
+			panic(invalid_binary)
+		}
+		return Decimal{Unscaled: unscaled, Scale: int(scale)}
+	case tag_float:
+		return Float{Value: d.rat()}
+	case tag_special_float:
+		f := d.float64()
+		if !math.IsInf(f, 0) && !math.IsNaN(f) {
+			panic(invalid_binary)
+		}
+		return ast.FloatNum(f)
+	case tag_exact_complex:
+		re := d.rat()
+		return ExactComplex{Re: re, Im: d.rat()}
+	case tag_complex:
+		re := d.float64()
+		return Complex{Value: complex(re, d.float64())}
+	case tag_char:
+		r := d.uvarint()
+		if r > unicode.MaxRune || !utf8.ValidRune(rune(r)) {
+			panic(invalid_binary)
+		}
+		return Char(rune(r))
+	case tag_str:
+		return Str{string(d.bytes())}
+	case tag_symbol:
+		name := d.bytes()
+		if len(name) == 0 || !utf8.Valid(name) {
+			panic(invalid_binary)
+		}
+		return Symbol{string(name)}
+	case tag_list:
+		if d.depth >= max_binary_depth {
+			panic(too_deep_binary)
+		}
+		d.depth++
+		// each item takes at least one byte, so the list grows with the data actually read
+		n := d.uvarint()
+		lst := List{}
+		for i := uint64(0); i < n; i++ {
+			lst = append(lst, d.value())
+		}
+		d.depth--
+		return lst
+	case tag_time:
+		t := time.Time{}
+		if err := t.UnmarshalBinary(d.bytes()); err != nil {
+			panic(err)
+		}
+		return Time{t}
+	case tag_duration:
+		return Duration{time.Duration(d.varint())}
+	case tag_regexp:
+		re, err := regexp.Compile(string(d.bytes()))
+		if err != nil {
+			panic(err)
+		}
+		return Regexp{re}
+	default:
+		panic(invalid_binary)
+	}
+}
+
+// Reads version byte and the value
+func unmarshal_from(r binary_reader) (v Any, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			if e_err, ok := e.(error); ok {
+				err = e_err
+			} else {
+				panic(e)
+			}
+		}
+	}()
+	d := binary_decoder{r: r}
+	if d.byte() != binary_version {
+		return nil, invalid_binary
+	}
+	return d.value(), nil
+}
+
+// UnmarshalBinary decodes lispy data encoded by MarshalBinary
+func UnmarshalBinary(data []byte) (Any, error) {
+	r := bytes.NewReader(data)
+	v, err := unmarshal_from(r)
+	if err == nil && r.Len() > 0 {
+		err = invalid_binary
+	}
+	return v, err
+}
+
+// (serialize value [port]) -> binary string, or writes it to the port
+func (env *Env) serialize(args ...Any) Any {
+	n_args("serialize", args, 1, 2, "1 or 2 arguments (value [port])")
+	b, err := MarshalBinary(args[0])
+	if err != nil {
+		panic(err)
+	}
+	if len(args) > 1 {
+		to_output_port(args[1]).write(string(b))
+		return nil
+	}
+	return Str{string(b)}
+}
+
+// (deserialize string-or-port) - from port reads the next value
+func deserialize(args ...Any) Any {
+	src := one_arg("deserialize", args)
+	var v Any
+	var err error
+	if p, ok := src.(*InputPort); ok {
+		v, err = unmarshal_from(p.reader)
+	} else {
+		v, err = UnmarshalBinary([]byte(to_str(src)))
+	}
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -0,0 +1,113 @@
+package lispy
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+func Test_serialize(t *testing.T) {
+	e := StdEnv()
+	values := []string{
+		"nil",
+		"(list nil t false)",
+		"123456789012345678901234567890",
+		"-7/3",
+		"#d-12.50",
+		"0.1",
+		"+inf.0",
+		"3+4i",
+		"1.5+2.0i",
+		"#\\λ",
+		"\"str\\nλ\"",
+		"'sym",
+		"'(a (b (c \"d\")) () (if x 1 2))",
+		"(make-duration 90)",
+		"(make-time 2024 1 31 10 0 0 5 \"Asia/Tokyo\")",
+		"(regexp-compile \"a+b\")",
+	}
+	for _, src := range values {
+		v := e.eval_expr(ParseStr(src))
+		b, err := MarshalBinary(v)
+		if err != nil {
+			t.Errorf("MarshalBinary(%s) failed: %v", src, err)
+			continue
+		}
+		r, err := UnmarshalBinary(b)
+		if err != nil {
+			t.Errorf("UnmarshalBinary(%s) failed: %v", src, err)
+			continue
+		}
+		if LispyStr(r) != LispyStr(v) || reflect.TypeOf(r) != reflect.TypeOf(v) {
+			t.Errorf("Not round-tripped: %s -> %s", LispyStr(v), LispyStr(r))
+		}
+	}
+
+	examples := [][]string{
+		{"(deserialize (serialize (list 1 \"a\" 'b)))", "'(1 \"a\" b)"},
+		{"(begin (define o (open-output-string)) (serialize 1/2 o) (serialize 'x o) (define i (open-input-string (get-output-string o))) (list (deserialize i) (deserialize i)))", "'(1/2 x)"},
+	}
+	run_eval_table(t, e, examples)
+
+	if _, err := MarshalBinary(List{Str{"f"}, e.symbol_lookup(Symbol{"car"})}); err == nil {
+		t.Errorf("Function is expected to be not serializable")
+	}
+	b, _ := MarshalBinary(List{Str{"abc"}, ast.IntNum(1)})
+	for i := 0; i < len(b); i++ {
+		if _, err := UnmarshalBinary(b[:i]); err == nil {
+			t.Errorf("Truncated data is expected to fail: %v", b[:i])
+		}
+	}
+
+	// malformed data is an error, not a crash
+	deep := []byte{binary_version}
+	for i := 0; i < 100000; i++ {
+		deep = append(deep, tag_list, 1)
+	}
+	deep = append(deep, tag_nil)
+	invalid := map[string][]byte{
+		"too deep":        deep,
+		"sign byte":       {binary_version, tag_int, 2, 1, 1},
+		"negative zero":   {binary_version, tag_int, 1, 0},
+		"leading zero":    {binary_version, tag_int, 0, 2, 0, 1},
+		"zero denom":      {binary_version, tag_rat, 0, 1, 1, 0, 0},
+		"integer rat":     {binary_version, tag_rat, 0, 1, 4, 0, 1, 2},
+		"finite special":  {binary_version, tag_special_float, 0x3f, 0xf0, 0, 0, 0, 0, 0, 0},
+		"surrogate char":  {binary_version, tag_char, 0x80, 0xb0, 0x03},
+		"empty symbol":    {binary_version, tag_symbol, 0},
+		"negative scale":  {binary_version, tag_decimal, 0, 1, 5, 1},
+		"huge scale":      {binary_version, tag_decimal, 0, 1, 5, 0xfe, 0xff, 0xff, 0xff, 0x0f},
+		"huge list count": {binary_version, tag_list, 0xff, 0xff, 0xff, 0xff, 0x0f},
+		"unknown tag":     {binary_version, 0xee},
+		"bad regexp":      {binary_version, tag_regexp, 1, '('},
+	}
+	for name, data := range invalid {
+		if _, err := UnmarshalBinary(data); err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+	if _, err := UnmarshalBinary(deep); !errors.Is(err, invalid_binary) || !strings.Contains(err.Error(), "nested deeper") {
+		t.Errorf("Unexpected error for too deep data: %v", err)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		data := make([]byte, rnd.Intn(32))
+		rnd.Read(data)
+		UnmarshalBinary(append([]byte{binary_version}, data...))
+	}
+
+	var nested Any = List{}
+	for i := 0; i < max_binary_depth+1; i++ {
+		nested = List{nested}
+	}
+	if _, err := MarshalBinary(nested); err == nil {
+		t.Errorf("Expected error for too deep list")
+	}
+	if _, err := MarshalBinary(Decimal{Unscaled: ast.IntNum(1).Value, Scale: max_decimal_scale + 1}); err == nil {
+		t.Errorf("Expected error for too large decimal scale")
+	}
+}
@@ -3,6 +3,11 @@ package lispy
 import (
 	"math"
 	"math/big"
+	"math/cmplx"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
 )
@@ -50,90 +55,164 @@ func bool_to_int(b Bool) Int {
 	}
 }
 
-func fold_nums(name string, f_i func(Int, Int) Int, f_f func(Float, Float) Float, init Any, args ...Any) Any {
-	var acc Any
-	acc = init
-	//TODO: type switch for multiple args
-	for _, item := range args {
-		switch a := acc.(type) {
-		case Int:
-			switch v := item.(type) {
-			case Bool:
-				acc = f_i(a, bool_to_int(v))
-			case Int:
-				acc = f_i(a, v)
-			case Float:
-				acc = f_f(int_to_float(a), v)
-			default:
-				panic("Invalid '" + name + "' argument: " + LispyStr(args))
-			}
-		case Float:
-			switch v := item.(type) {
-			case Bool:
-				acc = f_f(a, int_to_float(bool_to_int(v)))
-			case Int:
-				acc = f_f(a, int_to_float(v))
-			case Float:
-				acc = f_f(a, v)
-			default:
-				panic("Invalid '" + name + "' argument: " + LispyStr(args))
-			}
-		default:
-			panic("'" + name + "' Error")
+// Numeric tower: operands are promoted to the widest kind among them
+const (
+	num_int = iota
+	num_decimal
+	num_rat
+	num_float
+	num_exact_complex
+	num_complex
+)
+
+func num_kind(name string, n Any) int {
+	switch n.(type) {
+	case Bool, Int:
+		return num_int
+	case Decimal:
+		return num_decimal
+	case Rat:
+		return num_rat
+	case Float:
+		return num_float
+	case ExactComplex:
+		return num_exact_complex
+	case Complex:
+		return num_complex
+	default:
+		panic("Invalid '" + name + "' argument: " + LispyStr(n))
+	}
+}
+
+func to_int(n Any) Int {
+	switch v := n.(type) {
+	case Int:
+		return v
+	case Bool:
+		return bool_to_int(v)
+	default:
+		panic("Not an integer: " + LispyStr(n))
+	}
+}
+
+func to_rat(n Any) *big.Rat {
+	switch v := n.(type) {
+	case Rat:
+		return v.Value
+	case Decimal:
+		return v.Rat()
+	case Float:
+		if v.IsSpecial() {
+			panic("No exact representation: " + LispyStr(n))
+		}
+		return v.Value
+	default:
+		return new(big.Rat).SetInt(to_int(n).Value)
+	}
+}
+
+// Implementations of binary numeric operation for each level of the numeric tower
+type num_ops struct {
+	i func(a, b *big.Int) Any
+	d func(a, b Decimal) Decimal
+	r func(a, b *big.Rat) *big.Rat
+	f func(a, b float64) float64 // when one of arguments is inf or nan
+	x func(a, b ExactComplex) ExactComplex
+	c func(a, b complex128) complex128
+	// f is also used for inexact arguments when rhs is zero, e.g. for division
+	zero_rhs_inexact bool
+}
+
+func num_apply(name string, ops num_ops, lhs Any, rhs Any) Any {
+	l := num_kind(name, lhs)
+	r := num_kind(name, rhs)
+	kind := max(l, r)
+	if kind == num_exact_complex && (l == num_float || r == num_float) {
+		// exact complex loses exactness with inexact real
+		kind = num_complex
+	}
+
+	switch kind {
+	case num_int:
+		return ops.i(to_int(lhs).Value, to_int(rhs).Value)
+	case num_decimal:
+		return ops.d(to_decimal(lhs), to_decimal(rhs))
+	case num_rat:
+		return ast.ExactRat(ops.r(to_rat(lhs), to_rat(rhs)))
+	case num_exact_complex:
+		z := ops.x(to_exact_complex(lhs), to_exact_complex(rhs))
+		return ast.ExactComplexNum(z.Re, z.Im)
+	case num_complex:
+		return ast.ComplexNum(ops.c(to_complex128(lhs), to_complex128(rhs)))
+	default:
+		x := to_float(lhs)
+		y := to_float(rhs)
+		if x.IsSpecial() || y.IsSpecial() || (ops.zero_rhs_inexact && y.Value.Sign() == 0) {
+			return ast.FloatNum(ops.f(x.Float64(), y.Float64()))
 		}
+		return Float{Value: ops.r(x.Value, y.Value)}
 	}
+}
 
+func fold_nums(name string, ops num_ops, init Any, args ...Any) Any {
+	acc := init
+	for _, item := range args {
+		acc = num_apply(name, ops, acc, item)
+	}
 	return acc
 }
 
-func numeric_2_args(name string, f_i func(Int, Int) Int, f_f func(Float, Float) Float, args ...Any) Any {
+func numeric_2_args(name string, ops num_ops, args ...Any) Any {
 	if len(args) != 2 {
 		panic("'" + name + "' requires exactly 2 arguments, provided: " + LispyStr(args))
 	}
 
-	lhs := args[0]
-	rhs := args[1]
-
-	switch x := lhs.(type) {
-	case Int:
-		switch y := rhs.(type) {
-		case Int:
-			return f_i(x, y)
-		case Float:
-			return f_f(int_to_float(x), y)
-		default:
-			panic("Invalid '" + name + "' argument: " + LispyStr(args))
-		}
-	case Float:
-		switch y := rhs.(type) {
-		case Int:
-			return f_f(x, int_to_float(y))
-		case Float:
-			return f_f(x, y)
-		default:
-			panic("Invalid '" + name + "' argument: " + LispyStr(args))
-		}
-	default:
-		panic("'" + name + "' Error")
-	}
+	return num_apply(name, ops, args[0], args[1])
 }
 
 //TODO: Number type that holds the big.Int or big.Rat or anything else and converts it if necessary
 
+var sum_ops = num_ops{
+	i: func(a, b *big.Int) Any { return Int{new(big.Int).Add(a, b)} },
+	d: decimal_add,
+	r: func(a, b *big.Rat) *big.Rat { return new(big.Rat).Add(a, b) },
+	f: func(a, b float64) float64 { return a + b },
+	x: exact_complex_add,
+	c: func(a, b complex128) complex128 { return a + b },
+}
+
+var sub_ops = num_ops{
+	i: func(a, b *big.Int) Any { return Int{new(big.Int).Sub(a, b)} },
+	d: decimal_sub,
+	r: func(a, b *big.Rat) *big.Rat { return new(big.Rat).Sub(a, b) },
+	f: func(a, b float64) float64 { return a - b },
+	x: exact_complex_sub,
+	c: func(a, b complex128) complex128 { return a - b },
+}
+
+var prod_ops = num_ops{
+	i: func(a, b *big.Int) Any { return Int{new(big.Int).Mul(a, b)} },
+	d: decimal_mul,
+	r: func(a, b *big.Rat) *big.Rat { return new(big.Rat).Mul(a, b) },
+	f: func(a, b float64) float64 { return a * b },
+	x: exact_complex_mul,
+	c: func(a, b complex128) complex128 { return a * b },
+}
+
+// Division of integers results in Rat, inexact division by zero - in inf or nan
+var div_ops = num_ops{
+	i: func(a, b *big.Int) Any { return ast.ExactRat(new(big.Rat).SetFrac(a, b)) },
+	d: decimal_div,
+	r: func(a, b *big.Rat) *big.Rat { return new(big.Rat).Quo(a, b) },
+	f: func(a, b float64) float64 { return a / b },
+	x: exact_complex_div,
+	c: func(a, b complex128) complex128 { return a / b },
+
+	zero_rhs_inexact: true,
+}
+
 func sum(args ...Any) Any {
-	return fold_nums("+",
-		func(a Int, b Int) Int {
-			r := Int{big.NewInt(0)}
-			r.Value = r.Value.Add(a.Value, b.Value)
-			return r
-		},
-		func(a Float, b Float) Float {
-			r := ast.FloatNum(0)
-			r.Value = r.Value.Add(a.Value, b.Value)
-			return r
-		},
-		Int{big.NewInt(0)}, args...,
-	)
+	return fold_nums("+", sum_ops, ast.IntNum(0), args...)
 }
 
 func minus(arg Any) Any {
@@ -141,9 +220,21 @@ func minus(arg Any) Any {
 	case Int:
 		z := big.NewInt(0)
 		return Int{z.Neg(x.Value)}
+	case Rat:
+		z := big.NewRat(0, 1)
+		return Rat{z.Neg(x.Value)}
+	case Decimal:
+		return Decimal{Unscaled: new(big.Int).Neg(x.Unscaled), Scale: x.Scale}
 	case Float:
+		if x.IsSpecial() {
+			return ast.FloatNum(-x.Special)
+		}
 		z := big.NewRat(0, 1)
-		return Float{z.Neg(x.Value)}
+		return Float{Value: z.Neg(x.Value)}
+	case ExactComplex:
+		return ExactComplex{Re: new(big.Rat).Neg(x.Re), Im: new(big.Rat).Neg(x.Im)}
+	case Complex:
+		return Complex{-x.Value}
 	default:
 		panic("Invalid unary '-' argument: " + LispyStr(arg))
 	}
@@ -153,46 +244,15 @@ func sub(args ...Any) Any {
 	if len(args) == 1 {
 		return minus(args[0])
 	}
-	return numeric_2_args("-",
-		func(a Int, b Int) Int {
-			r := Int{big.NewInt(0)}
-			r.Value = r.Value.Sub(a.Value, b.Value)
-			return r
-		},
-		func(a Float, b Float) Float {
-			r := ast.FloatNum(0)
-			r.Value = r.Value.Sub(a.Value, b.Value)
-			return r
-		},
-		args...,
-	)
+	return numeric_2_args("-", sub_ops, args...)
 }
 
 func prod(args ...Any) Any {
-	return fold_nums("*",
-		func(a Int, b Int) Int {
-			r := Int{big.NewInt(0)}
-			r.Value = r.Value.Mul(a.Value, b.Value)
-			return r
-		},
-		func(a Float, b Float) Float {
-			r := ast.FloatNum(0)
-			r.Value = r.Value.Mul(a.Value, b.Value)
-			return r
-		},
-		Int{big.NewInt(1)}, args...,
-	)
+	return fold_nums("*", prod_ops, ast.IntNum(1), args...)
 }
 
 func div(args ...Any) Any {
-	return numeric_2_floats("/",
-		func(a Float, b Float) Any {
-			r := ast.FloatNum(0)
-			r.Value = r.Value.Quo(a.Value, b.Value)
-			return r
-		},
-		args...,
-	)
+	return numeric_2_args("/", div_ops, args...)
 }
 
 func numeric_2_floats(name string, f func(Float, Float) Any, args ...Any) Any {
@@ -200,47 +260,55 @@ func numeric_2_floats(name string, f func(Float, Float) Any, args ...Any) Any {
 		panic("'" + name + "' requires exactly 2 arguments, provided: " + LispyStr(args))
 	}
 
-	lhs := args[0]
-	rhs := args[1]
-
-	switch x := lhs.(type) {
-	case Int:
-		switch y := rhs.(type) {
-		case Int:
-			return f(int_to_float(x), int_to_float(y))
-		case Float:
-			return f(int_to_float(x), y)
-		default:
-			panic("Invalid '" + name + "' argument: " + LispyStr(args))
+	for _, arg := range args {
+		if num_kind(name, arg) > num_float {
+			panic("Invalid '" + name + "' argument, complex numbers are not ordered: " + LispyStr(arg))
 		}
-	case Float:
-		switch y := rhs.(type) {
-		case Int:
-			return f(x, int_to_float(y))
-		case Float:
-			return f(x, y)
+	}
+
+	return f(to_float(args[0]), to_float(args[1]))
+}
+
+// Compare floats, ok is false if one of them is nan
+func float_cmp(a Float, b Float) (r int, ok bool) {
+	if a.IsSpecial() || b.IsSpecial() {
+		x := a.Float64()
+		y := b.Float64()
+		switch {
+		case math.IsNaN(x) || math.IsNaN(y):
+			return 0, false
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
 		default:
-			panic("Invalid '" + name + "' argument: " + LispyStr(args))
+			return 0, true
 		}
-	default:
-		panic("'" + name + "' Error")
 	}
+	return a.Value.Cmp(b.Value), true
+}
+
+func compare_nums(name string, pred func(int) bool, args ...Any) Any {
+	return numeric_2_floats(name, func(a Float, b Float) Any {
+		c, ok := float_cmp(a, b)
+		return Bool(ok && pred(c))
+	}, args...)
 }
 
 func gt(args ...Any) Any {
-	return numeric_2_floats(">", func(a Float, b Float) Any { return Bool(a.Value.Cmp(b.Value) > 0) }, args...)
+	return compare_nums(">", func(c int) bool { return c > 0 }, args...)
 }
 
 func lt(args ...Any) Any {
-	return numeric_2_floats(">", func(a Float, b Float) Any { return Bool(a.Value.Cmp(b.Value) < 0) }, args...)
+	return compare_nums("<", func(c int) bool { return c < 0 }, args...)
 }
 
 func ge(args ...Any) Any {
-	return numeric_2_floats(">", func(a Float, b Float) Any { return Bool(a.Value.Cmp(b.Value) >= 0) }, args...)
+	return compare_nums(">=", func(c int) bool { return c >= 0 }, args...)
 }
 
 func le(args ...Any) Any {
-	return numeric_2_floats(">", func(a Float, b Float) Any { return Bool(a.Value.Cmp(b.Value) <= 0) }, args...)
+	return compare_nums("<=", func(c int) bool { return c <= 0 }, args...)
 }
 
 func list_cmp(a List, b List) Bool {
@@ -273,15 +341,58 @@ func equal(a Any, b Any) Bool {
 		default:
 			return Bool(false)
 		}
+	case Rat:
+		switch y := b.(type) {
+		case Rat:
+			return x.Value.Cmp(y.Value) == 0
+		default:
+			return Bool(false)
+		}
+	case Decimal:
+		switch y := b.(type) {
+		case Decimal:
+			return x.Rat().Cmp(y.Rat()) == 0
+		default:
+			return Bool(false)
+		}
 	case Float:
 		switch y := b.(type) {
 		case Float:
-			return x.Value.Cmp(y.Value) == 0
+			c, ok := float_cmp(x, y)
+			return Bool(ok && c == 0)
+		default:
+			return Bool(false)
+		}
+	case ExactComplex:
+		switch y := b.(type) {
+		case ExactComplex:
+			return x.Re.Cmp(y.Re) == 0 && x.Im.Cmp(y.Im) == 0
+		default:
+			return Bool(false)
+		}
+	case Complex:
+		switch y := b.(type) {
+		case Complex:
+			return x.Value == y.Value
+		default:
+			return Bool(false)
+		}
+	case Time:
+		switch y := b.(type) {
+		case Time:
+			return Bool(x.Equal(y.Time))
+		default:
+			return Bool(false)
+		}
+	case GoObject:
+		switch y := b.(type) {
+		case GoObject:
+			return Bool(x.same(y))
 		default:
 			return Bool(false)
 		}
 	default:
-		return Bool(a == b)
+		return Bool(same(reflect.ValueOf(a), reflect.ValueOf(b)))
 	}
 }
 
@@ -293,6 +404,43 @@ func eq(args ...Any) Any {
 	return equal(args[0], args[1])
 }
 
+// Identity of objects: lists, functions and other references are the same if they point to the same object,
+// values of uncomparable types are never the same
+func same(a, b reflect.Value) (r bool) {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return a.Pointer() == b.Pointer()
+	case reflect.Slice:
+		return a.Pointer() == b.Pointer() && a.Len() == b.Len()
+	}
+	if !a.Type().Comparable() || !a.CanInterface() {
+		return false
+	}
+	// comparable structs could hold uncomparable values in interface fields, e.g. quoted lists
+	defer func() {
+		if recover() != nil {
+			r = false
+		}
+	}()
+	return a.Interface() == b.Interface()
+}
+
+// Identity of objects, Go objects are the same if they point to the same value
+func eq_p(args ...Any) Any {
+	n_args("eq?", args, 2, 2, "exactly 2 arguments")
+	if a, ok := args[0].(GoObject); ok {
+		b, ok := args[1].(GoObject)
+		return Bool(ok && a.same(b))
+	}
+	return Bool(same(reflect.ValueOf(args[0]), reflect.ValueOf(args[1])))
+}
+
 //TODO: package constraints is not in GOROOT
 //func max[T constraints.Ordered](a T, b T) T {
 func max(a int, b int) int {
@@ -377,6 +525,12 @@ func to_float(n Any) Float {
 		return v
 	case Int:
 		return int_to_float(v)
+	case Rat:
+		return Float{Value: v.Value}
+	case Decimal:
+		return Float{Value: v.Rat()}
+	case Bool:
+		return int_to_float(bool_to_int(v))
 	default:
 		panic("NAN: " + ast.String(n))
 	}
@@ -404,13 +558,48 @@ func pow(args ...Any) Any {
 		panic("'pow' requires exactly 2 arguments, provided: " + LispyStr(args))
 	}
 
-	base := to_float(args[0])
-	exp := to_float(args[1])
+	if is_complex(args[0]) || is_complex(args[1]) {
+		return ast.ComplexNum(cmplx.Pow(to_complex128(args[0]), to_complex128(args[1])))
+	}
+
+	base := to_float(args[0]).Float64()
+	exp := to_float(args[1]).Float64()
 
-	b, _ := base.Value.Float64()
-	e, _ := exp.Value.Float64()
+	if base < 0 && exp != math.Trunc(exp) {
+		// fractional power of negative number is complex
+		return ast.ComplexNum(cmplx.Pow(complex(base, 0), complex(exp, 0)))
+	}
+
+	return ast.FloatNum(math.Pow(base, exp))
+}
+
+// (number->string z [radix]), radix other than 10 is allowed only for exact numbers
+func number_to_string(format NumberFormat, args ...Any) Any {
+	if len(args) < 1 || len(args) > 2 {
+		panic("'number->string' requires 1 or 2 arguments (number [radix]), provided: " + LispyStr(args))
+	}
+
+	n := args[0]
+	num_kind("number->string", n)
+
+	if len(args) == 2 {
+		radix := to_int(args[1]).Value
+		switch radix.Int64() {
+		case 2, 8, 10, 16:
+		default:
+			panic("Invalid radix: " + LispyStr(args[1]))
+		}
+		format.Radix = int(radix.Int64())
+		if format.Radix != 10 {
+			switch n.(type) {
+			case Int, Rat, ExactComplex:
+			default:
+				panic("Radix " + LispyStr(args[1]) + " is supported only for exact integer, rational or complex numbers: " + LispyStr(n))
+			}
+		}
+	}
 
-	return ast.FloatNum(math.Pow(b, e))
+	return Str{Value: LispyStr(n, format)}
 }
 
 func StdEnv() *Env {
@@ -420,6 +609,10 @@ func StdEnv() *Env {
 		"enable-print-elapsed": Bool(false),
 		"enable-trace":         Bool(false),
 
+		// number printing policy: full, shortest, fixed, scientific or exact
+		"float-format": Symbol{Name: "shortest"},
+		"float-digits": ast.IntNum(6),
+
 		"car":  car,
 		"cdr":  cdr,
 		"cons": cons,
@@ -436,7 +629,7 @@ func StdEnv() *Env {
 		//TODO: common way to check the number of args and the types
 		"begin":  func(args ...Any) Any { return args[len(args)-1] },
 		"pi":     ast.FloatNum(math.Pi),
-		"eq?":    func(args ...Any) Any { return Bool(args[0] == args[1]) },
+		"eq?":    eq_p,
 		"equal?": eq,
 		"length": func(args ...Any) Any { return Int{big.NewInt(int64(len(to_list(args[0]))))} },
 		"not":    func(args ...Any) Any { return Bool(!if_test(args[0])) },
@@ -446,7 +639,177 @@ func StdEnv() *Env {
 		"map":    lispy_map,
 
 		"pow": pow,
+
+		"exp":  transcendental("exp", math.Exp, cmplx.Exp, nil),
+		"log":  transcendental("log", math.Log, cmplx.Log, func(x float64) bool { return x >= 0 }),
+		"sin":  transcendental("sin", math.Sin, cmplx.Sin, nil),
+		"cos":  transcendental("cos", math.Cos, cmplx.Cos, nil),
+		"tan":  transcendental("tan", math.Tan, cmplx.Tan, nil),
+		"asin": transcendental("asin", math.Asin, cmplx.Asin, func(x float64) bool { return x >= -1 && x <= 1 }),
+		"acos": transcendental("acos", math.Acos, cmplx.Acos, func(x float64) bool { return x >= -1 && x <= 1 }),
+		"atan": atan,
+		"sqrt": sqrt,
+
+		"round-half-even": decimal_rounding("round-half-even", round_half_even),
+		"round-half-up":   decimal_rounding("round-half-up", round_half_up),
+		"decimal-scale":   decimal_scale,
+
+		"number->string": func(args ...Any) Any { return number_to_string(env.NumberFormat(), args...) },
+
+		"char->integer":    char_to_integer,
+		"integer->char":    integer_to_char,
+		"char-upcase":      char_mapping("char-upcase", unicode.ToUpper),
+		"char-downcase":    char_mapping("char-downcase", unicode.ToLower),
+		"char-alphabetic?": char_predicate("char-alphabetic?", unicode.IsLetter),
+		"char-numeric?":    char_predicate("char-numeric?", unicode.IsDigit),
+		"char-whitespace?": char_predicate("char-whitespace?", unicode.IsSpace),
+		"char-upper-case?": char_predicate("char-upper-case?", unicode.IsUpper),
+		"char-lower-case?": char_predicate("char-lower-case?", unicode.IsLower),
+
+		"string-length":   string_length,
+		"string-ref":      string_ref,
+		"string-append":   string_append,
+		"substring":       substring,
+		"string-split":    string_split,
+		"string-join":     string_join,
+		"string-index":    string_index,
+		"string-contains": string_contains,
+		"string-upcase":   string_mapping("string-upcase", strings.ToUpper),
+		"string-downcase": string_mapping("string-downcase", strings.ToLower),
+		"string-trim":     string_trim,
+		"string->number":  string_to_number,
+		"string->symbol":  string_to_symbol,
+		"symbol->string":  symbol_to_string,
+		"string->list":    string_to_list,
+		"list->string":    list_to_string,
+		"string=?":        string_compare("string=?", func(c int) bool { return c == 0 }, same_string),
+		"string<?":        string_compare("string<?", func(c int) bool { return c < 0 }, same_string),
+		"string>?":        string_compare("string>?", func(c int) bool { return c > 0 }, same_string),
+		"string<=?":       string_compare("string<=?", func(c int) bool { return c <= 0 }, same_string),
+		"string>=?":       string_compare("string>=?", func(c int) bool { return c >= 0 }, same_string),
+		"string-ci=?":     string_compare("string-ci=?", func(c int) bool { return c == 0 }, strings.ToLower),
+		"string-ci<?":     string_compare("string-ci<?", func(c int) bool { return c < 0 }, strings.ToLower),
+		"string-ci>?":     string_compare("string-ci>?", func(c int) bool { return c > 0 }, strings.ToLower),
+
+		"format": env.format,
+
+		"current-input-port":    env.current_input_port,
+		"current-output-port":   env.current_output_port,
+		"current-error-port":    env.current_error_port,
+		"display":               env.display,
+		"write":                 env.write,
+		"write-string":          env.write_string,
+		"write-char":            env.write_char,
+		"newline":               env.newline,
+		"read-line":             env.read_line,
+		"read-char":             env.read_char,
+		"peek-char":             env.peek_char,
+		"open-input-string":     open_input_string,
+		"open-output-string":    open_output_string,
+		"get-output-string":     get_output_string,
+		"with-output-to-string": env.with_output_to_string,
+		"eof-object":            eof_object,
+		"eof-object?":           is_eof_object,
+		"close-port":            close_port,
+		"close-input-port":      close_port,
+		"close-output-port":     close_port,
+
+		"read":                    env.read,
+		"read-from-string":        read_from_string,
+		"eval":                    env.eval,
+		"interaction-environment": env.interaction_environment,
+		"make-environment":        env.make_environment,
+
+		"open-input-file":        env.open_input_file,
+		"open-output-file":       env.open_output_file,
+		"call-with-input-file":   env.call_with_input_file,
+		"call-with-output-file":  env.call_with_output_file,
+		"file-exists?":           env.file_exists,
+		"delete-file":            env.delete_file,
+		"rename-file":            env.rename_file,
+		"directory-list":         env.directory_list,
+		"make-directory":         env.make_directory,
+		"file-size":              env.file_size,
+		"file-modification-time": env.file_modification_time,
+
+		"run-process":  env.run_process,
+		"run-pipeline": env.run_pipeline,
+
+		"command-line":              env.command_line,
+		"exit":                      exit,
+		"emergency-exit":            env.emergency_exit,
+		"get-environment-variable":  env.get_environment_variable,
+		"get-environment-variables": env.get_environment_variables,
+		"set-environment-variable!": env.set_environment_variable,
+
+		"current-time":       env.current_time,
+		"current-jiffy":      env.current_jiffy,
+		"jiffies-per-second": jiffies_per_second,
+		"sleep":              env.sleep,
+		"time?":              is_time,
+		"make-time":          make_time,
+		"parse-time":         parse_time,
+		"format-time":        format_time,
+		"time-in-zone":       time_in_zone,
+		"time-add":           time_add,
+		"time-add-date":      time_add_date,
+		"time-difference":    time_difference,
+		"time<?":             time_compare("time<?", time.Time.Before),
+		"time>?":             time_compare("time>?", time.Time.After),
+		"time=?":             time_compare("time=?", time.Time.Equal),
+		"time-year":          time_field("time-year", time.Time.Year),
+		"time-month":         time_field("time-month", func(t time.Time) int { return int(t.Month()) }),
+		"time-day":           time_field("time-day", time.Time.Day),
+		"time-hour":          time_field("time-hour", time.Time.Hour),
+		"time-minute":        time_field("time-minute", time.Time.Minute),
+		"time-second":        time_field("time-second", time.Time.Second),
+		"time-nanosecond":    time_field("time-nanosecond", time.Time.Nanosecond),
+		"time-weekday":       time_weekday,
+		"time-zone-name":     time_zone_name,
+		"time->seconds":      time_to_seconds,
+		"seconds->time":      seconds_to_time,
+		"duration?":          is_duration,
+		"make-duration":      make_duration,
+		"parse-duration":     parse_duration,
+		"duration->seconds":  duration_to_seconds,
+
+		"json-parse":     json_parse,
+		"json-stringify": json_stringify,
+		"json-write":     env.json_write,
+		"json-ref":       json_ref,
+
+		"csv-read":  csv_read,
+		"csv-write": env.csv_write,
+
+		"go-object?": is_go_object,
+		"go-type?":   is_go_type,
+
+		"serialize":   env.serialize,
+		"deserialize": deserialize,
+
+		"regexp-compile":   regexp_compile,
+		"regexp-match":     regexp_match,
+		"regexp-match-all": regexp_match_all,
+		"regexp-replace":   regexp_replace,
+		"regexp-split":     regexp_split,
+
+		"random-seed!":       env.random_seed,
+		"random-integer":     env.random_integer,
+		"random-real":        env.random_real,
+		"random-normal":      env.random_normal,
+		"random-exponential": env.random_exponential,
+		"random-choice":      env.random_choice,
+		"shuffle":            env.shuffle,
+
+		"make-rectangular": make_rectangular,
+		"make-polar":       make_polar,
+		"real-part":        real_part,
+		"imag-part":        imag_part,
+		"magnitude":        magnitude,
+		"angle":            angle,
 	}
 
+	env.ports = std_ports()
+
 	return &env
 }
@@ -0,0 +1,215 @@
+package lispy
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+// All string functions count characters in runes, not bytes
+
+func to_str(s Any) string {
+	switch v := s.(type) {
+	case Str:
+		return v.Value
+	default:
+		panic("Invalid string: " + LispyStr(s))
+	}
+}
+
+// Index argument as int, checked to be in [0, size)
+func to_index(name string, i Any, size int) int {
+	n := to_int(i).Value
+	if !n.IsInt64() || n.Sign() < 0 || n.Int64() >= int64(size) {
+		panic("'" + name + "' index out of range: " + LispyStr(i))
+	}
+	return int(n.Int64())
+}
+
+// Negative max means no upper limit
+func n_args(name string, args []Any, min int, max int, usage string) {
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		panic("'" + name + "' requires " + usage + ", provided: " + LispyStr(args))
+	}
+}
+
+// Index of the substring in runes, -1 if not found
+func rune_index(s string, sub string) int {
+	i := strings.Index(s, sub)
+	if i < 0 {
+		return -1
+	}
+	return utf8.RuneCountInString(s[:i])
+}
+
+func index_or_false(i int) Any {
+	if i < 0 {
+		return Bool(false)
+	}
+	return ast.IntNum(int64(i))
+}
+
+func string_length(args ...Any) Any {
+	return ast.IntNum(int64(utf8.RuneCountInString(to_str(one_arg("string-length", args)))))
+}
+
+// (string-ref s k) -> k-th character
+func string_ref(args ...Any) Any {
+	n_args("string-ref", args, 2, 2, "exactly 2 arguments (string index)")
+	s := []rune(to_str(args[0]))
+	return Char(s[to_index("string-ref", args[1], len(s))])
+}
+
+func string_append(args ...Any) Any {
+	var b strings.Builder
+	for _, arg := range args {
+		b.WriteString(to_str(arg))
+	}
+	return Str{b.String()}
+}
+
+// (substring s start [end])
+func substring(args ...Any) Any {
+	n_args("substring", args, 2, 3, "2 or 3 arguments (string start [end])")
+	s := []rune(to_str(args[0]))
+	start := to_index("substring", args[1], len(s)+1)
+	end := len(s)
+	if len(args) == 3 {
+		end = to_index("substring", args[2], len(s)+1)
+	}
+	if end < start {
+		panic("'substring' end is before start: " + LispyStr(args))
+	}
+	return Str{string(s[start:end])}
+}
+
+// (string-split s [separator]), splits by whitespace if separator is not provided
+func string_split(args ...Any) Any {
+	n_args("string-split", args, 1, 2, "1 or 2 arguments (string [separator])")
+	s := to_str(args[0])
+	var parts []string
+	if len(args) == 2 {
+		parts = strings.Split(s, to_str(args[1]))
+	} else {
+		parts = strings.Fields(s)
+	}
+	r := List{}
+	for _, p := range parts {
+		r = append(r, Str{p})
+	}
+	return r
+}
+
+// (string-join list [separator]), separator is a space by default
+func string_join(args ...Any) Any {
+	n_args("string-join", args, 1, 2, "1 or 2 arguments (list [separator])")
+	sep := " "
+	if len(args) == 2 {
+		sep = to_str(args[1])
+	}
+	return Str{strings.Join(ast.Map(to_str, to_list(args[0])), sep)}
+}
+
+// (string-index s char) -> index or false
+func string_index(args ...Any) Any {
+	n_args("string-index", args, 2, 2, "exactly 2 arguments (string char)")
+	return index_or_false(rune_index(to_str(args[0]), string(rune(to_char(args[1])))))
+}
+
+// (string-contains s sub) -> index or false
+func string_contains(args ...Any) Any {
+	n_args("string-contains", args, 2, 2, "exactly 2 arguments (string substring)")
+	return index_or_false(rune_index(to_str(args[0]), to_str(args[1])))
+}
+
+func string_mapping(name string, f func(string) string) PureFunction {
+	return func(args ...Any) Any {
+		return Str{f(to_str(one_arg(name, args)))}
+	}
+}
+
+// (string-trim s [chars]), trims whitespace if chars are not provided
+func string_trim(args ...Any) Any {
+	n_args("string-trim", args, 1, 2, "1 or 2 arguments (string [chars])")
+	s := to_str(args[0])
+	if len(args) == 2 {
+		return Str{strings.Trim(s, to_str(args[1]))}
+	}
+	return Str{strings.TrimFunc(s, unicode.IsSpace)}
+}
+
+var radix_prefix = map[int64]string{2: "#b", 8: "#o", 10: "", 16: "#x"}
+
+// (string->number s [radix]) -> number or false if s is not a number
+func string_to_number(args ...Any) (r Any) {
+	n_args("string->number", args, 1, 2, "1 or 2 arguments (string [radix])")
+	s := to_str(args[0])
+	if len(args) == 2 {
+		prefix, ok := radix_prefix[to_int(args[1]).Value.Int64()]
+		if !ok {
+			panic("Invalid radix: " + LispyStr(args[1]))
+		}
+		s = prefix + s
+	}
+
+	defer func() {
+		if recover() != nil {
+			r = Bool(false)
+		}
+	}()
+
+	seq := ParseStr(s)
+	if len(seq) != 1 {
+		return Bool(false)
+	}
+	if _, ok := seq[0].(Bool); ok {
+		return Bool(false)
+	}
+	num_kind("string->number", seq[0])
+	return seq[0]
+}
+
+func string_to_symbol(args ...Any) Any {
+	return Symbol{Name: to_str(one_arg("string->symbol", args))}
+}
+
+func symbol_to_string(args ...Any) Any {
+	return Str{to_symbol(one_arg("symbol->string", args)).Name}
+}
+
+func string_to_list(args ...Any) Any {
+	r := List{}
+	for _, c := range to_str(one_arg("string->list", args)) {
+		r = append(r, Char(c))
+	}
+	return r
+}
+
+func list_to_string(args ...Any) Any {
+	var b strings.Builder
+	for _, c := range to_list(one_arg("list->string", args)) {
+		b.WriteRune(rune(to_char(c)))
+	}
+	return Str{b.String()}
+}
+
+// Comparison of all adjacent pairs of arguments: (string<? a b c)
+func string_compare(name string, pred func(int) bool, fold func(string) string) PureFunction {
+	return func(args ...Any) Any {
+		if len(args) < 2 {
+			panic("'" + name + "' requires at least 2 arguments, provided: " + LispyStr(args))
+		}
+		for i := 1; i < len(args); i++ {
+			if !pred(strings.Compare(fold(to_str(args[i-1])), fold(to_str(args[i])))) {
+				return Bool(false)
+			}
+		}
+		return Bool(true)
+	}
+}
+
+func same_string(s string) string {
+	return s
+}
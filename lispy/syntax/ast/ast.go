@@ -3,8 +3,13 @@ package ast
 import (
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
+	"math/cmplx"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/agutikov/go-lisp-experiments/lispy/syntax/token"
 )
@@ -27,10 +32,36 @@ type Int struct {
 	Value *big.Int
 }
 
+type Rat struct {
+	Value *big.Rat
+}
+
 type Float struct {
 	Value *big.Rat
+	// big.Rat can't hold infinities and nan,
+	// so for them Value is nil and Special keeps the IEEE value
+	Special float64
+}
+
+// Exact fixed-point decimal number: Unscaled * 10^-Scale
+type Decimal struct {
+	Unscaled *big.Int
+	Scale    int
+}
+
+// Complex number with exact rational components
+type ExactComplex struct {
+	Re *big.Rat
+	Im *big.Rat
+}
+
+// Inexact complex number
+type Complex struct {
+	Value complex128
 }
 
+type Char rune
+
 type Str struct {
 	Value string
 }
@@ -75,38 +106,378 @@ func NewSymbol(t Attrib) (Symbol, error) {
 	return Symbol{name}, nil
 }
 
-func NewInt(t Attrib) (Int, error) {
-	s := string(t.(*token.Token).Lit)
+// Numeric literal prefixes: #x, #o, #b set the radix, #e and #i force exactness
+type num_prefix struct {
+	radix int
+	exact byte // 'e', 'i' or 0 if not specified
+}
+
+func parse_num_prefix(s string) (num_prefix, string, error) {
+	p := num_prefix{radix: 10}
+	radix_set := false
+	for len(s) > 1 && s[0] == '#' {
+		switch c := unicode.ToLower(rune(s[1])); c {
+		case 'x', 'o', 'b':
+			if radix_set {
+				return p, s, errors.New("duplicated radix prefix")
+			}
+			radix_set = true
+			switch c {
+			case 'x':
+				p.radix = 16
+			case 'o':
+				p.radix = 8
+			case 'b':
+				p.radix = 2
+			}
+		case 'e', 'i':
+			if p.exact != 0 {
+				return p, s, errors.New("duplicated exactness prefix")
+			}
+			p.exact = byte(c)
+		default:
+			return p, s, errors.New("unknown prefix")
+		}
+		s = s[2:]
+	}
+	return p, s, nil
+}
+
+func literal_error(kind string, t *token.Token, err error) error {
+	msg := fmt.Sprintf("invalid %s literal %q at %d:%d", kind, string(t.Lit), t.Pos.Line, t.Pos.Column)
+	if err != nil {
+		msg += ": " + err.Error()
+	}
+	return errors.New(msg)
+}
 
-	n := new(big.Int)
-	n, ok := n.SetString(s, 10)
+// Parse exact number: integer or rational with optional radix
+func parse_exact(s string, radix int) (*big.Rat, error) {
+	parts := strings.SplitN(s, "/", 2)
+
+	num, ok := new(big.Int).SetString(parts[0], radix)
 	if !ok {
-		return Int{nil}, errors.New("invalid Int literal: \"" + s + "\"")
+		return nil, errors.New("bad numerator")
+	}
+	den := big.NewInt(1)
+	if len(parts) == 2 {
+		den, ok = den.SetString(parts[1], radix)
+		if !ok {
+			return nil, errors.New("bad denominator")
+		}
+		if den.Sign() == 0 {
+			return nil, errors.New("division by zero")
+		}
 	}
 
-	return Int{n}, nil
+	return new(big.Rat).SetFrac(num, den), nil
+}
+
+// Returns Int if the rational is integer, Rat otherwise
+func ExactRat(r *big.Rat) Any {
+	if r.IsInt() {
+		return Int{new(big.Int).Set(r.Num())}
+	}
+	return Rat{r}
+}
+
+// NewInt parses literals with exact syntax: integers and rationals,
+// optionally with radix and exactness prefixes.
+// Returns Int, Rat, or Float if #i prefix is used.
+func NewInt(t Attrib) (Any, error) {
+	tok := t.(*token.Token)
+
+	p, s, err := parse_num_prefix(string(tok.Lit))
+	if err != nil {
+		return nil, literal_error("Int", tok, err)
+	}
+
+	r, err := parse_exact(s, p.radix)
+	if err != nil {
+		return nil, literal_error("Int", tok, err)
+	}
+
+	if p.exact == 'i' {
+		return Float{Value: r}, nil
+	}
+	return ExactRat(r), nil
 }
 
 func IntNum(i int64) Int {
 	return Int{big.NewInt(i)}
 }
 
+func RatNum(a, b int64) Rat {
+	return Rat{big.NewRat(a, b)}
+}
+
 func FloatNum(f float64) Float {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return Float{Special: f}
+	}
 	r := new(big.Rat)
 	r.SetFloat64(f)
-	return Float{r}
+	return Float{Value: r}
 }
 
-func NewFloat(t Attrib) (Float, error) {
-	s := string(t.(*token.Token).Lit)
+var special_floats = map[string]float64{
+	"+inf.0": math.Inf(1),
+	"-inf.0": math.Inf(-1),
+	"+nan.0": math.NaN(),
+	"-nan.0": math.NaN(),
+}
+
+// NewFloat parses literals with inexact syntax: decimal point, exponent, +inf.0, -inf.0, +nan.0.
+// Returns Float, or Int or Rat if #e prefix is used.
+func NewFloat(t Attrib) (Any, error) {
+	tok := t.(*token.Token)
+
+	p, s, err := parse_num_prefix(string(tok.Lit))
+	if err != nil {
+		return nil, literal_error("Float", tok, err)
+	}
+
+	if special, ok := special_floats[s]; ok {
+		if p.exact == 'e' {
+			return nil, literal_error("Float", tok, errors.New("no exact representation"))
+		}
+		return FloatNum(special), nil
+	}
 
-	f := new(big.Rat)
-	n, ok := f.SetString(s)
+	r, ok := new(big.Rat).SetString(s)
 	if !ok {
-		return Float{nil}, errors.New("invalid Float literal: \"" + s + "\"")
+		return nil, literal_error("Float", tok, nil)
+	}
+
+	if p.exact == 'e' {
+		return ExactRat(r), nil
+	}
+	return Float{Value: r}, nil
+}
+
+func DecimalNum(unscaled int64, scale int) Decimal {
+	return Decimal{Unscaled: big.NewInt(unscaled), Scale: scale}
+}
+
+// NewDecimal parses fixed-point decimal literal "#d12.50", scale is the number of digits after the point
+func NewDecimal(t Attrib) (Any, error) {
+	tok := t.(*token.Token)
+	s := string(tok.Lit[2:])
+
+	scale := 0
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		scale = len(s) - i - 1
+		s = s[:i] + s[i+1:]
+	}
+
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, literal_error("Decimal", tok, nil)
+	}
+
+	return Decimal{Unscaled: n, Scale: scale}, nil
+}
+
+func Pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func (this Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(this.Unscaled, Pow10(this.Scale))
+}
+
+// Parse real decimal number, exactness is defined by syntax
+func parse_real(s string) (Any, error) {
+	if special, ok := special_floats[s]; ok {
+		return FloatNum(special), nil
+	}
+	if strings.ContainsAny(s, ".eE") {
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return nil, errors.New("bad real part")
+		}
+		return Float{Value: r}, nil
+	}
+	r, err := parse_exact(s, 10)
+	if err != nil {
+		return nil, err
+	}
+	return ExactRat(r), nil
+}
+
+// Exact value of real number, ok is false for inexact numbers
+func exact_rat(v Any) (r *big.Rat, ok bool) {
+	switch x := v.(type) {
+	case Int:
+		return new(big.Rat).SetInt(x.Value), true
+	case Rat:
+		return x.Value, true
+	default:
+		return nil, false
+	}
+}
+
+func real_float64(v Any) float64 {
+	switch x := v.(type) {
+	case Float:
+		return x.Float64()
+	default:
+		r, _ := exact_rat(v)
+		f, _ := r.Float64()
+		return f
+	}
+}
+
+// Index of the sign that separates real and imaginary parts in "3+4i", 0 if there is no real part
+func imag_part_index(s string) int {
+	for i := len(s) - 1; i > 0; i-- {
+		if (s[i] == '+' || s[i] == '-') && s[i-1] != 'e' && s[i-1] != 'E' {
+			return i
+		}
+	}
+	return 0
+}
+
+// NewComplex parses rectangular "3+4i", "-i", "1/2-2.5i" and polar "2@1.57" literals.
+// Result is exact if all components have exact syntax,
+// and is normalized to real number if imaginary part is zero.
+func NewComplex(t Attrib) (Any, error) {
+	tok := t.(*token.Token)
+
+	p, s, err := parse_num_prefix(string(tok.Lit))
+	if err != nil {
+		return nil, literal_error("Complex", tok, err)
+	}
+	if p.radix != 10 {
+		return nil, literal_error("Complex", tok, errors.New("only decimal radix is supported"))
+	}
+
+	var re_s, im_s string
+	polar := false
+	if i := strings.IndexByte(s, '@'); i >= 0 {
+		re_s, im_s = s[:i], s[i+1:]
+		polar = true
+	} else {
+		s = s[:len(s)-1]
+		i := imag_part_index(s)
+		re_s, im_s = s[:i], s[i:]
+		if re_s == "" {
+			re_s = "0"
+		}
+		if im_s == "+" || im_s == "-" {
+			im_s += "1"
+		}
+	}
+
+	re, err := parse_real(re_s)
+	if err != nil {
+		return nil, literal_error("Complex", tok, err)
+	}
+	im, err := parse_real(im_s)
+	if err != nil {
+		return nil, literal_error("Complex", tok, err)
+	}
+
+	re_r, re_exact := exact_rat(re)
+	im_r, im_exact := exact_rat(im)
+	exact := re_exact && im_exact && p.exact != 'i'
+
+	if polar {
+		if exact && im_r.Sign() == 0 {
+			return ExactRat(re_r), nil
+		}
+		if p.exact == 'e' {
+			return nil, literal_error("Complex", tok, errors.New("no exact representation"))
+		}
+		return ComplexNum(cmplx.Rect(real_float64(re), real_float64(im))), nil
+	}
+
+	if p.exact == 'e' && !exact {
+		var ok bool
+		if re_r, ok = exact_float(re); !ok {
+			return nil, literal_error("Complex", tok, errors.New("no exact representation"))
+		}
+		if im_r, ok = exact_float(im); !ok {
+			return nil, literal_error("Complex", tok, errors.New("no exact representation"))
+		}
+		exact = true
+	}
+
+	if exact {
+		return ExactComplexNum(re_r, im_r), nil
+	}
+	return ComplexNum(complex(real_float64(re), real_float64(im))), nil
+}
+
+// Exact value of real number of any kind, ok is false for inf and nan
+func exact_float(v Any) (*big.Rat, bool) {
+	if f, ok := v.(Float); ok {
+		return f.Value, !f.IsSpecial()
+	}
+	return exact_rat(v)
+}
+
+// Returns real number if imaginary part is zero
+func ExactComplexNum(re *big.Rat, im *big.Rat) Any {
+	if im.Sign() == 0 {
+		return ExactRat(re)
+	}
+	return ExactComplex{Re: re, Im: im}
+}
+
+// Returns Float if imaginary part is zero
+func ComplexNum(c complex128) Any {
+	if imag(c) == 0 {
+		return FloatNum(real(c))
+	}
+	return Complex{c}
+}
+
+// Special float is one of: +inf.0, -inf.0, +nan.0
+func (this Float) IsSpecial() bool {
+	return this.Value == nil
+}
+
+func (this Float) Float64() float64 {
+	if this.IsSpecial() {
+		return this.Special
+	}
+	f, _ := this.Value.Float64()
+	return f
+}
+
+var char_names = map[string]Char{
+	"alarm":     '\a',
+	"backspace": '\b',
+	"delete":    0x7f,
+	"escape":    0x1b,
+	"newline":   '\n',
+	"null":      0,
+	"return":    '\r',
+	"space":     ' ',
+	"tab":       '\t',
+}
+
+// NewChar parses character literals: #\a, #\λ, #\space, #\x3bb
+func NewChar(t Attrib) (Char, error) {
+	tok := t.(*token.Token)
+	s := string(tok.Lit[2:])
+
+	if utf8.RuneCountInString(s) == 1 {
+		r, _ := utf8.DecodeRuneInString(s)
+		return Char(r), nil
+	}
+	if c, ok := char_names[s]; ok {
+		return c, nil
+	}
+	if s[0] == 'x' || s[0] == 'X' {
+		code, err := strconv.ParseUint(s[1:], 16, 32)
+		if err == nil && utf8.ValidRune(rune(code)) {
+			return Char(code), nil
+		}
 	}
 
-	return Float{n}, nil
+	return 0, literal_error("Char", tok, errors.New("unknown character name"))
 }
 
 func str_replace_escaped(s string) string {
@@ -196,11 +567,87 @@ func (this Int) String() string {
 	return this.Value.String()
 }
 
+func (this Rat) String() string {
+	return this.Value.RatString()
+}
+
 func (this Float) String() string {
-	f := big.NewFloat(0)
-	f.SetPrec(0)
-	f.SetRat(this.Value)
-	return f.Text('f', int(f.MinPrec())) //TODO: get precision from env
+	return format_inexact(this.Float64())
+}
+
+func (this Decimal) String() string {
+	digits := new(big.Int).Abs(this.Unscaled).String()
+	sign := ""
+	if this.Unscaled.Sign() < 0 {
+		sign = "-"
+	}
+	if this.Scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= this.Scale {
+		digits = strings.Repeat("0", this.Scale-len(digits)+1) + digits
+	}
+	point := len(digits) - this.Scale
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Inexact number representation that is never confused with exact one: 1.0, 1e+21, +inf.0
+func format_inexact(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "+nan.0"
+	case math.IsInf(f, 1):
+		return "+inf.0"
+	case math.IsInf(f, -1):
+		return "-inf.0"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// Imaginary part always has a sign, exact 1 is omitted: "+i", "-2i", "+1.0i"
+func format_imag(s string) string {
+	switch s {
+	case "1":
+		s = ""
+	case "-1":
+		s = "-"
+	}
+	if !strings.HasPrefix(s, "-") && !strings.HasPrefix(s, "+") {
+		s = "+" + s
+	}
+	return s + "i"
+}
+
+func (this ExactComplex) String() string {
+	s := format_imag(this.Im.RatString())
+	if this.Re.Sign() != 0 {
+		s = this.Re.RatString() + s
+	}
+	return s
+}
+
+func (this Complex) String() string {
+	s := format_imag(format_inexact(imag(this.Value)))
+	if real(this.Value) != 0 {
+		s = format_inexact(real(this.Value)) + s
+	}
+	return s
+}
+
+func (this Char) String() string {
+	for name, c := range char_names {
+		if c == this {
+			return "#\\" + name
+		}
+	}
+	if unicode.IsGraphic(rune(this)) {
+		return "#\\" + string(rune(this))
+	}
+	return fmt.Sprintf("#\\x%x", rune(this))
 }
 
 func (this Symbol) String() string {
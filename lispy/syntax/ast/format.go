@@ -0,0 +1,132 @@
+package ast
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+type FloatFormat int
+
+const (
+	// Shortest representation that round-trips through float64, used by String()
+	FloatShortest FloatFormat = iota
+	// All digits of the stored value
+	FloatFull
+	// Fixed number of digits after the point
+	FloatFixed
+	// Scientific notation with fixed number of digits after the point
+	FloatScientific
+	// Exact rational value of inexact number: #i1/10
+	FloatExact
+)
+
+var float_format_names = []string{"shortest", "full", "fixed", "scientific", "exact"}
+
+func (this FloatFormat) String() string {
+	return float_format_names[this]
+}
+
+func ParseFloatFormat(name string) (FloatFormat, bool) {
+	for i, n := range float_format_names {
+		if n == name {
+			return FloatFormat(i), true
+		}
+	}
+	return FloatShortest, false
+}
+
+// Printing policy for numbers, zero value prints numbers as String() does
+type NumberFormat struct {
+	Float FloatFormat
+	// Digits after the point for FloatFixed and FloatScientific
+	Digits int
+	// Radix for exact numbers, 0 means 10
+	Radix int
+}
+
+func (f NumberFormat) radix() int {
+	if f.Radix == 0 {
+		return 10
+	}
+	return f.Radix
+}
+
+func (f NumberFormat) format_float64(x float64) string {
+	switch f.Float {
+	case FloatFixed:
+		return strconv.FormatFloat(x, 'f', f.Digits, 64)
+	case FloatScientific:
+		return strconv.FormatFloat(x, 'e', f.Digits, 64)
+	default:
+		return format_inexact(x)
+	}
+}
+
+func (f NumberFormat) FormatFloat(x Float) string {
+	if x.IsSpecial() {
+		return x.String()
+	}
+	switch f.Float {
+	case FloatFull:
+		v := new(big.Float).SetPrec(0).SetRat(x.Value)
+		return v.Text('f', int(v.MinPrec()))
+	case FloatFixed:
+		return x.Value.FloatString(f.Digits)
+	case FloatScientific:
+		return new(big.Float).SetPrec(256).SetRat(x.Value).Text('e', f.Digits)
+	case FloatExact:
+		return "#i" + x.Value.RatString()
+	default:
+		return format_inexact(x.Float64())
+	}
+}
+
+func (f NumberFormat) FormatComplex(x Complex) string {
+	s := format_imag(f.format_float64(imag(x.Value)))
+	if real(x.Value) != 0 {
+		s = f.format_float64(real(x.Value)) + s
+	}
+	return s
+}
+
+func (f NumberFormat) FormatRat(x *big.Rat) string {
+	if x.IsInt() {
+		return x.Num().Text(f.radix())
+	}
+	return x.Num().Text(f.radix()) + "/" + x.Denom().Text(f.radix())
+}
+
+func (f NumberFormat) FormatExactComplex(x ExactComplex) string {
+	s := format_imag(f.FormatRat(x.Im))
+	if x.Re.Sign() != 0 {
+		s = f.FormatRat(x.Re) + s
+	}
+	return s
+}
+
+// Format numbers in the expression with given policy
+func Format(this Any, f NumberFormat) string {
+	switch v := this.(type) {
+	case Int:
+		return v.Value.Text(f.radix())
+	case Rat:
+		return f.FormatRat(v.Value)
+	case Float:
+		return f.FormatFloat(v)
+	case Complex:
+		return f.FormatComplex(v)
+	case ExactComplex:
+		return f.FormatExactComplex(v)
+	case List:
+		return "(" + strings.Join(Map(func(a Any) string { return Format(a, f) }, v), " ") + ")"
+	case Sequence:
+		return strings.Join(Map(func(a Any) string { return Format(a, f) }, v), "\n")
+	case Quote:
+		return "'" + Format(v.Value, f)
+	case Unquote:
+		return "," + Format(v.Value, f)
+	default:
+		return String(this)
+	}
+}
@@ -0,0 +1,154 @@
+package ast
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// BigInt parses decimal integer, it's used by Go source generated with GoSource
+func BigInt(s string) *big.Int {
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("Invalid integer: " + s)
+	}
+	return i
+}
+
+// BigRat parses rational "a/b", it's used by Go source generated with GoSource
+func BigRat(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic("Invalid rational: " + s)
+	}
+	return r
+}
+
+// SpecialFloat returns +inf.0, -inf.0, +nan.0 or -0.0, it's used by Go source generated with GoSource
+func SpecialFloat(name string) float64 {
+	if name == "-0.0" {
+		return math.Copysign(0, -1)
+	}
+	f, ok := special_floats[name]
+	if !ok {
+		panic("Invalid special float: " + name)
+	}
+	return f
+}
+
+// GoSource returns Go expression building the same AST without the parser,
+// e.g. ast.List{ast.Symbol{Name: "+"}, ast.Int{Value: ast.BigInt("1")}}.
+// The expression refers only to this package imported as "ast".
+func GoSource(this Any) string {
+	w := &strings.Builder{}
+	write_go_source(w, this)
+	return w.String()
+}
+
+func go_float(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return `ast.SpecialFloat("+inf.0")`
+	case math.IsInf(f, -1):
+		return `ast.SpecialFloat("-inf.0")`
+	case math.IsNaN(f):
+		return `ast.SpecialFloat("+nan.0")`
+	case f == 0 && math.Signbit(f):
+		return `ast.SpecialFloat("-0.0")`
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+func write_go_items[T any](w *strings.Builder, items []T) {
+	w.WriteString("{")
+	if len(items) > 0 {
+		w.WriteString("\n")
+	}
+	for _, item := range items {
+		write_go_source(w, item)
+		w.WriteString(",\n")
+	}
+	w.WriteString("}")
+}
+
+func write_go_source(w *strings.Builder, this Any) {
+	switch v := this.(type) {
+	case nil:
+		w.WriteString("nil")
+	case Nil:
+		w.WriteString("ast.Nil{}")
+	case Bool:
+		fmt.Fprintf(w, "ast.Bool(%t)", bool(v))
+	case Symbol:
+		fmt.Fprintf(w, "ast.Symbol{Name: %s}", strconv.Quote(v.Name))
+	case Int:
+		fmt.Fprintf(w, "ast.Int{Value: ast.BigInt(%q)}", v.Value.String())
+	case Rat:
+		fmt.Fprintf(w, "ast.Rat{Value: ast.BigRat(%q)}", v.Value.RatString())
+	case Float:
+		if v.IsSpecial() {
+			fmt.Fprintf(w, "ast.Float{Special: %s}", go_float(v.Special))
+		} else {
+			fmt.Fprintf(w, "ast.Float{Value: ast.BigRat(%q)}", v.Value.RatString())
+		}
+	case Decimal:
+		fmt.Fprintf(w, "ast.Decimal{Unscaled: ast.BigInt(%q), Scale: %d}", v.Unscaled.String(), v.Scale)
+	case ExactComplex:
+		fmt.Fprintf(w, "ast.ExactComplex{Re: ast.BigRat(%q), Im: ast.BigRat(%q)}", v.Re.RatString(), v.Im.RatString())
+	case Complex:
+		fmt.Fprintf(w, "ast.Complex{Value: complex(%s, %s)}", go_float(real(v.Value)), go_float(imag(v.Value)))
+	case Char:
+		fmt.Fprintf(w, "ast.Char(%s)", strconv.QuoteRune(rune(v)))
+	case Str:
+		fmt.Fprintf(w, "ast.Str{Value: %s}", strconv.Quote(v.Value))
+	case Quote:
+		w.WriteString("ast.Quote{Value: ")
+		write_go_source(w, v.Value)
+		w.WriteString("}")
+	case Unquote:
+		w.WriteString("ast.Unquote{Value: ")
+		write_go_source(w, v.Value)
+		w.WriteString("}")
+	case List:
+		w.WriteString("ast.List")
+		write_go_items(w, v)
+	case Sequence:
+		w.WriteString("ast.Sequence")
+		write_go_items(w, v)
+	case If:
+		w.WriteString("ast.If{\nTest: ")
+		write_go_source(w, v.Test)
+		w.WriteString(",\nPosBranch: ")
+		write_go_source(w, v.PosBranch)
+		w.WriteString(",\nNegBranch: ")
+		write_go_source(w, v.NegBranch)
+		w.WriteString(",\n}")
+	case Define:
+		fmt.Fprintf(w, "ast.Define{\nSym: ast.Symbol{Name: %s},\nValue: ", strconv.Quote(v.Sym.Name))
+		write_go_source(w, v.Value)
+		w.WriteString(",\n}")
+	case Set:
+		fmt.Fprintf(w, "ast.Set{\nSym: ast.Symbol{Name: %s},\nValue: ", strconv.Quote(v.Sym.Name))
+		write_go_source(w, v.Value)
+		w.WriteString(",\n}")
+	case Lambda:
+		w.WriteString("ast.Lambda{\nArgs: []ast.Symbol{")
+		for i, arg := range v.Args {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			fmt.Fprintf(w, "{Name: %s}", strconv.Quote(arg.Name))
+		}
+		w.WriteString("},\nBody: ")
+		write_go_source(w, v.Body)
+		w.WriteString(",\n}")
+	default:
+		panic(fmt.Sprintf("GoSource: unsupported AST node %T", this))
+	}
+}
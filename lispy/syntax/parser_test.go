@@ -1,7 +1,10 @@
 package parser
 
 import (
+	"math"
+	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
@@ -26,6 +29,46 @@ func Test_Sexpr(t *testing.T) {
 			ast.Symbol{"+"}, ast.IntNum(99), ast.IntNum(-1000),
 		}},
 
+		{"+5", ast.IntNum(5)},
+		{"#xff", ast.IntNum(255)},
+		{"#X-1F", ast.IntNum(-31)},
+		{"#o17", ast.IntNum(15)},
+		{"#b-101", ast.IntNum(-5)},
+		{"2/3", ast.RatNum(2, 3)},
+		{"-4/2", ast.IntNum(-2)},
+		{"#x1/a", ast.RatNum(1, 10)},
+		{".5", ast.FloatNum(0.5)},
+		{"-1.5e1", ast.FloatNum(-15)},
+		{"#e1.5", ast.RatNum(3, 2)},
+		{"#e1e3", ast.IntNum(1000)},
+		{"#i1/4", ast.FloatNum(0.25)},
+		{"#x#e10", ast.IntNum(16)},
+		{"#e#b10", ast.IntNum(2)},
+		{"+inf.0", ast.FloatNum(math.Inf(1))},
+		{"-inf.0", ast.FloatNum(math.Inf(-1))},
+		{"3+4i", ast.ExactComplex{Re: big.NewRat(3, 1), Im: big.NewRat(4, 1)}},
+		{"-i", ast.ExactComplex{Re: big.NewRat(0, 1), Im: big.NewRat(-1, 1)}},
+		{"1/2-3/4i", ast.ExactComplex{Re: big.NewRat(1, 2), Im: big.NewRat(-3, 4)}},
+		{"#e1.5+.5i", ast.ExactComplex{Re: big.NewRat(3, 2), Im: big.NewRat(1, 2)}},
+		{"1e-2+2.5e1i", ast.Complex{complex(0.01, 25)}},
+		{"#i3-i", ast.Complex{complex(3, -1)}},
+		{"+inf.0i", ast.Complex{complex(0, math.Inf(1))}},
+		{"5+0i", ast.IntNum(5)},
+		{"2@0", ast.IntNum(2)},
+		{"#d12.50", ast.DecimalNum(1250, 2)},
+		{"#d-.05", ast.DecimalNum(-5, 2)},
+		{"#D7", ast.DecimalNum(7, 0)},
+		{"#\\a", ast.Char('a')},
+		{"#\\λ", ast.Char('λ')},
+		{"#\\(", ast.Char('(')},
+		{"#\\space", ast.Char(' ')},
+		{"#\\x3bb", ast.Char('λ')},
+		{"(λ #\\a)", ast.List{ast.Symbol{"λ"}, ast.Char('a')}},
+		{"число", ast.Symbol{"число"}},
+		{"(+ 1/2 .5)", ast.List{
+			ast.Symbol{"+"}, ast.RatNum(1, 2), ast.FloatNum(0.5),
+		}},
+
 		{"\"\"", ast.Str{""}},
 		{"\"a\"", ast.Str{"a"}},
 		{"\" \"", ast.Str{" "}},
@@ -56,6 +99,14 @@ func Test_Sexpr(t *testing.T) {
 			Body: ast.List{ast.Symbol{"-"}, ast.Symbol{"x"}},
 		}},
 
+		{"(lambda () 1)", ast.Lambda{
+			Args: []ast.Symbol{},
+			Body: ast.IntNum(1),
+		}},
+
+		{"(. obj Method .5)", ast.List{ast.Symbol{"."}, ast.Symbol{"obj"}, ast.Symbol{"Method"}, ast.FloatNum(0.5)}},
+		{"(.- obj Field)", ast.List{ast.Symbol{".-"}, ast.Symbol{"obj"}, ast.Symbol{"Field"}}},
+
 		{"(if t false ())", ast.If{
 			Test:      ast.Bool(true),
 			PosBranch: ast.Bool(false),
@@ -137,3 +188,34 @@ func Test_Sequence(t *testing.T) {
 		}
 	}
 }
+
+func Test_InvalidNumber(t *testing.T) {
+	tests := []string{
+		"1/0",
+		"(+ 1\n  #b1/0)",
+		"#e+inf.0",
+		"#e1.0@1",
+		"1/0+i",
+		"#\\foo",
+	}
+
+	p := parser.NewParser()
+
+	for _, input := range tests {
+		lex := lexer.NewLexer([]byte(input))
+		_, err := p.Parse(lex)
+		if err == nil {
+			t.Fatalf("Invalid literal parsed without error: %q", input)
+		}
+		t.Logf("%q -> %v", input, err)
+		if !strings.Contains(err.Error(), "invalid") {
+			t.Errorf("Unexpected error for %q: %v", input, err)
+		}
+	}
+
+	lex := lexer.NewLexer([]byte("(+ 1\n  #b1/0)"))
+	_, err := p.Parse(lex)
+	if !strings.Contains(err.Error(), "at 2:3") {
+		t.Errorf("Literal position is not reported: %v", err)
+	}
+}
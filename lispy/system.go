@@ -0,0 +1,120 @@
+package lispy
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Exit is the panic value of (exit [code]),
+// deferred functions run while it unwinds and the host decides what to do with the code
+type Exit struct {
+	Code int
+}
+
+func (this Exit) String() string {
+	return fmt.Sprintf("exit %d", this.Code)
+}
+
+func (this Exit) Error() string {
+	return this.String()
+}
+
+// DisableHost makes emergency-exit, environment variables and sleep on the system clock
+// unavailable for the interpreter, e.g. for sandboxed scripts
+func (env *Env) DisableHost() {
+	env.root().no_host = true
+}
+
+func (env *Env) check_host(name string) {
+	if env.root().no_host {
+		panic("'" + name + "' is disabled")
+	}
+}
+
+// SetCommandLine sets the script arguments returned by (command-line)
+func (env *Env) SetCommandLine(args []string) {
+	env.root().script_args = args
+}
+
+func (env *Env) command_line(args ...Any) Any {
+	n_args("command-line", args, 0, 0, "no arguments")
+	r := List{}
+	for _, a := range env.root().script_args {
+		r = append(r, Str{a})
+	}
+	return r
+}
+
+// t or no argument means success, false - failure, or exact integer code
+func exit_code(name string, args []Any) int {
+	n_args(name, args, 0, 1, "0 or 1 argument ([code])")
+	if len(args) == 0 {
+		return 0
+	}
+	switch v := args[0].(type) {
+	case Bool:
+		if v {
+			return 0
+		}
+		return 1
+	case Int:
+		if v.Value.IsInt64() {
+			return int(v.Value.Int64())
+		}
+	}
+	panic("Invalid '" + name + "' code: " + LispyStr(args[0]))
+}
+
+func exit(args ...Any) Any {
+	panic(Exit{exit_code("exit", args)})
+}
+
+// Terminates the process immediately, without unwinding
+func (env *Env) emergency_exit(args ...Any) Any {
+	env.check_host("emergency-exit")
+	os.Exit(exit_code("emergency-exit", args))
+	return nil
+}
+
+// (get-environment-variable name) -> value or false if not set
+func (env *Env) get_environment_variable(args ...Any) Any {
+	env.check_host("get-environment-variable")
+	v, ok := os.LookupEnv(to_str(one_arg("get-environment-variable", args)))
+	if !ok {
+		return Bool(false)
+	}
+	return Str{v}
+}
+
+// (get-environment-variables) -> list of (name value) sorted by names
+func (env *Env) get_environment_variables(args ...Any) Any {
+	env.check_host("get-environment-variables")
+	n_args("get-environment-variables", args, 0, 0, "no arguments")
+	vars := os.Environ()
+	sort.Strings(vars)
+	r := List{}
+	for _, kv := range vars {
+		name, value, _ := strings.Cut(kv, "=")
+		r = append(r, List{Str{name}, Str{value}})
+	}
+	return r
+}
+
+// (set-environment-variable! name value) - false value removes the variable
+func (env *Env) set_environment_variable(args ...Any) Any {
+	env.check_host("set-environment-variable!")
+	n_args("set-environment-variable!", args, 2, 2, "exactly 2 arguments (name value)")
+	name := to_str(args[0])
+	var err error
+	if b, ok := args[1].(Bool); ok && !bool(b) {
+		err = os.Unsetenv(name)
+	} else {
+		err = os.Setenv(name, to_str(args[1]))
+	}
+	if err != nil {
+		panic(err)
+	}
+	return nil
+}
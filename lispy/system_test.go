@@ -0,0 +1,71 @@
+package lispy
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+func Test_system(t *testing.T) {
+	t.Setenv("LISPY_TEST_VAR", "value")
+	out := &strings.Builder{}
+	e := StdEnvWithIO(strings.NewReader(""), out, out)
+	e.SetCommandLine([]string{"-v", "file.txt"})
+
+	examples := [][]string{
+		{"(command-line)", "'(\"-v\" \"file.txt\")"},
+		{"(get-environment-variable \"LISPY_TEST_VAR\")", "\"value\""},
+		{"(set-environment-variable! \"LISPY_TEST_VAR\" false)", "nil"},
+		{"(get-environment-variable \"LISPY_TEST_VAR\")", "false"},
+		{"(set-environment-variable! \"LISPY_TEST_VAR\" \"new\")", "nil"},
+	}
+	run_eval_table(t, e, examples)
+
+	found := false
+	for _, kv := range to_list(e.Eval(ParseStr("(get-environment-variables)")).(ast.Quote).Value) {
+		found = found || LispyStr(kv) == "(\"LISPY_TEST_VAR\" \"new\")"
+	}
+	if !found {
+		t.Errorf("get-environment-variables has no LISPY_TEST_VAR")
+	}
+
+	for expr, code := range map[string]int{"(exit)": 0, "(exit 7)": 7, "(exit false)": 1, "(exit t)": 0} {
+		func() {
+			defer func() {
+				r := recover()
+				if r != (Exit{code}) {
+					t.Errorf("Expected exit %d for %q, got: %v", code, expr, r)
+				}
+			}()
+			e.Eval(ParseStr("(with-output-to-string (lambda () " + expr + "))"))
+		}()
+	}
+	// exit unwinds through with-output-to-string and restores the output port
+	e.Eval(ParseStr("(display \"ok\")"))
+	if out.String() != "ok" {
+		t.Errorf("Output port not restored after exit: %q", out.String())
+	}
+}
+
+func Test_WithoutHost(t *testing.T) {
+	t.Setenv("LISPY_TEST_VAR", "value")
+	errs := map[string]string{
+		"(emergency-exit 7)":                                     "'emergency-exit' is disabled",
+		"(get-environment-variable \"LISPY_TEST_VAR\")":          "'get-environment-variable' is disabled",
+		"(get-environment-variables)":                            "'get-environment-variables' is disabled",
+		"(set-environment-variable! \"LISPY_TEST_VAR\" \"new\")": "'set-environment-variable!' is disabled",
+		"(sleep 10)": "'sleep' is disabled",
+	}
+	run_error_table(t, New(WithoutIO()), errs)
+	run_error_table(t, New(WithoutHost()), errs)
+	if v := os.Getenv("LISPY_TEST_VAR"); v != "value" {
+		t.Errorf("Environment variable changed in sandbox: %q", v)
+	}
+
+	// sleep on the given clock is allowed
+	e := New(WithoutIO(), WithClock(&fake_clock{now: time.Unix(0, 0)}))
+	run_eval_table(t, e, [][]string{{"(begin (sleep 10) (current-jiffy))", "10000000000"}})
+}
@@ -0,0 +1,8 @@
+; every kind of AST node, used by Test_GenerateGo
+(define fact (lambda (n) (if (<= n 1) 1 (* n (fact (- n 1))))))
+(define counter 0)
+(set! counter (+ counter 1))
+(define numbers (list 123456789012345678901234567890 -3/4 1.5 -0.0 +inf.0 #d12.50 1+2i 1.5+2.5i #\λ "a \"quoted\"\nstring"))
+(define data '(sym nil t false (nested ,counter) ()))
+(define thunk (lambda () counter))
+(list (fact 20) counter (length numbers) data (thunk))
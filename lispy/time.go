@@ -0,0 +1,283 @@
+package lispy
+
+import (
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+// Clock is the source of time for current-time, current-jiffy and sleep
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type system_clock struct{}
+
+func (system_clock) Now() time.Time {
+	return time.Now()
+}
+
+func (system_clock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// SetClock replaces the system clock of the interpreter, e.g. with a fake one in tests
+func (env *Env) SetClock(c Clock) {
+	env.root().clock = c
+}
+
+// Clock returns the clock of the interpreter
+func (env *Env) Clock() Clock {
+	root := env.root()
+	if root.clock == nil {
+		return system_clock{}
+	}
+	return root.clock
+}
+
+type Time struct {
+	time.Time
+}
+
+type Duration struct {
+	time.Duration
+}
+
+func (this Time) String() string {
+	return "#<time " + this.Format(time.RFC3339Nano) + ">"
+}
+
+func (this Duration) String() string {
+	return "#<duration " + this.Duration.String() + ">"
+}
+
+func to_time(t Any) time.Time {
+	if v, ok := t.(Time); ok {
+		return v.Time
+	}
+	panic("Invalid time: " + LispyStr(t))
+}
+
+// Duration value or real number of seconds
+// Number of seconds rounded to the nearest nanosecond
+func to_nanoseconds(name string, d Any) *big.Int {
+	ns := new(big.Rat).Mul(to_rat(real_arg(name, d)), big.NewRat(int64(time.Second), 1))
+	q, m := new(big.Int).QuoRem(ns.Num(), ns.Denom(), new(big.Int))
+	if new(big.Int).Lsh(m.Abs(m), 1).Cmp(ns.Denom()) >= 0 {
+		q.Add(q, big.NewInt(int64(ns.Sign())))
+	}
+	return q
+}
+
+func to_duration(name string, d Any) time.Duration {
+	if v, ok := d.(Duration); ok {
+		return v.Duration
+	}
+	q := to_nanoseconds(name, d)
+	if !q.IsInt64() {
+		panic("Invalid '" + name + "' argument, duration is out of range: " + LispyStr(d))
+	}
+	return time.Duration(q.Int64())
+}
+
+func to_location(z Any) *time.Location {
+	loc, err := time.LoadLocation(to_str(z))
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+var time_layouts = map[string]string{
+	"rfc3339":      time.RFC3339,
+	"rfc3339-nano": time.RFC3339Nano,
+	"rfc1123":      time.RFC1123,
+	"rfc822":       time.RFC822,
+	"kitchen":      time.Kitchen,
+	"date":         "2006-01-02",
+	"time":         "15:04:05",
+	"datetime":     "2006-01-02 15:04:05",
+}
+
+// Go layout string or name of the predefined layout
+func to_layout(l Any) string {
+	if s, ok := l.(Symbol); ok {
+		if layout, ok := time_layouts[s.Name]; ok {
+			return layout
+		}
+		panic("Unknown time layout: " + s.Name)
+	}
+	return to_str(l)
+}
+
+func (env *Env) current_time(args ...Any) Any {
+	n_args("current-time", args, 0, 0, "no arguments")
+	return Time{env.Clock().Now()}
+}
+
+// Nanoseconds since Unix epoch
+func (env *Env) current_jiffy(args ...Any) Any {
+	n_args("current-jiffy", args, 0, 0, "no arguments")
+	return ast.IntNum(env.Clock().Now().UnixNano())
+}
+
+func jiffies_per_second(args ...Any) Any {
+	n_args("jiffies-per-second", args, 0, 0, "no arguments")
+	return ast.IntNum(int64(time.Second))
+}
+
+// (sleep duration-or-seconds)
+func (env *Env) sleep(args ...Any) Any {
+	if env.root().clock == nil {
+		env.check_host("sleep")
+	}
+	env.Clock().Sleep(to_duration("sleep", one_arg("sleep", args)))
+	return nil
+}
+
+// (parse-time string layout [zone]) - zone is used if the string has no zone, UTC by default
+func parse_time(args ...Any) Any {
+	n_args("parse-time", args, 2, 3, "2 or 3 arguments (string layout [zone])")
+	loc := time.UTC
+	if len(args) > 2 {
+		loc = to_location(args[2])
+	}
+	t, err := time.ParseInLocation(to_layout(args[1]), to_str(args[0]), loc)
+	if err != nil {
+		panic(err)
+	}
+	return Time{t}
+}
+
+// (format-time time layout)
+func format_time(args ...Any) Any {
+	n_args("format-time", args, 2, 2, "exactly 2 arguments (time layout)")
+	return Str{to_time(args[0]).Format(to_layout(args[1]))}
+}
+
+// (make-time year month day [hour minute second nanosecond [zone]])
+func make_time(args ...Any) Any {
+	n_args("make-time", args, 3, 8, "3 to 8 arguments (year month day [hour minute second nanosecond [zone]])")
+	f := [7]int{}
+	for i := 0; i < len(args) && i < 7; i++ {
+		v := to_int(args[i]).Value
+		if !v.IsInt64() {
+			panic("Invalid 'make-time' argument: " + LispyStr(args[i]))
+		}
+		f[i] = int(v.Int64())
+	}
+	loc := time.UTC
+	if len(args) > 7 {
+		loc = to_location(args[7])
+	}
+	return Time{time.Date(f[0], time.Month(f[1]), f[2], f[3], f[4], f[5], f[6], loc)}
+}
+
+// (time-in-zone time zone) - the same moment in another time zone
+func time_in_zone(args ...Any) Any {
+	n_args("time-in-zone", args, 2, 2, "exactly 2 arguments (time zone)")
+	return Time{to_time(args[0]).In(to_location(args[1]))}
+}
+
+// (time-add time duration-or-seconds)
+func time_add(args ...Any) Any {
+	n_args("time-add", args, 2, 2, "exactly 2 arguments (time duration)")
+	return Time{to_time(args[0]).Add(to_duration("time-add", args[1]))}
+}
+
+// (time-add-date time years months days)
+func time_add_date(args ...Any) Any {
+	n_args("time-add-date", args, 4, 4, "exactly 4 arguments (time years months days)")
+	d := [3]int{}
+	for i := range d {
+		v := to_int(args[i+1]).Value
+		if !v.IsInt64() {
+			panic("Invalid 'time-add-date' argument: " + LispyStr(args[i+1]))
+		}
+		d[i] = int(v.Int64())
+	}
+	return Time{to_time(args[0]).AddDate(d[0], d[1], d[2])}
+}
+
+// (time-difference a b) -> duration a - b
+func time_difference(args ...Any) Any {
+	n_args("time-difference", args, 2, 2, "exactly 2 arguments (time time)")
+	return Duration{to_time(args[0]).Sub(to_time(args[1]))}
+}
+
+func time_compare(name string, pred func(a, b time.Time) bool) PureFunction {
+	return func(args ...Any) Any {
+		n_args(name, args, 2, 2, "exactly 2 arguments (time time)")
+		return Bool(pred(to_time(args[0]), to_time(args[1])))
+	}
+}
+
+func time_field(name string, field func(t time.Time) int) PureFunction {
+	return func(args ...Any) Any {
+		return ast.IntNum(int64(field(to_time(one_arg(name, args)))))
+	}
+}
+
+func time_weekday(args ...Any) Any {
+	return Symbol{strings.ToLower(to_time(one_arg("time-weekday", args)).Weekday().String())}
+}
+
+func time_zone_name(args ...Any) Any {
+	name, _ := to_time(one_arg("time-zone-name", args)).Zone()
+	return Str{name}
+}
+
+// (time->seconds time) -> exact number of seconds since Unix epoch
+func time_to_seconds(args ...Any) Any {
+	t := to_time(one_arg("time->seconds", args))
+	ns := new(big.Int).Mul(big.NewInt(t.Unix()), big.NewInt(int64(time.Second)))
+	ns.Add(ns, big.NewInt(int64(t.Nanosecond())))
+	return ast.ExactRat(new(big.Rat).SetFrac(ns, big.NewInt(int64(time.Second))))
+}
+
+// (seconds->time seconds) -> time in UTC
+func seconds_to_time(args ...Any) Any {
+	s := one_arg("seconds->time", args)
+	sec, ns := new(big.Int).DivMod(to_nanoseconds("seconds->time", s), big.NewInt(int64(time.Second)), new(big.Int))
+	if !sec.IsInt64() {
+		panic("Invalid 'seconds->time' argument, time is out of range: " + LispyStr(s))
+	}
+	return Time{time.Unix(sec.Int64(), ns.Int64()).UTC()}
+}
+
+// (make-duration seconds)
+func make_duration(args ...Any) Any {
+	return Duration{to_duration("make-duration", one_arg("make-duration", args))}
+}
+
+// (parse-duration "1h30m")
+func parse_duration(args ...Any) Any {
+	d, err := time.ParseDuration(to_str(one_arg("parse-duration", args)))
+	if err != nil {
+		panic(err)
+	}
+	return Duration{d}
+}
+
+// (duration->seconds duration) -> exact number of seconds
+func duration_to_seconds(args ...Any) Any {
+	d := one_arg("duration->seconds", args)
+	v, ok := d.(Duration)
+	if !ok {
+		panic("Invalid duration: " + LispyStr(d))
+	}
+	return ast.ExactRat(big.NewRat(int64(v.Duration), int64(time.Second)))
+}
+
+func is_time(args ...Any) Any {
+	_, ok := one_arg("time?", args).(Time)
+	return Bool(ok)
+}
+
+func is_duration(args ...Any) Any {
+	_, ok := one_arg("duration?", args).(Duration)
+	return Bool(ok)
+}
@@ -0,0 +1,47 @@
+package lispy
+
+import (
+	"testing"
+	"time"
+)
+
+type fake_clock struct {
+	now time.Time
+}
+
+func (c *fake_clock) Now() time.Time {
+	return c.now
+}
+
+func (c *fake_clock) Sleep(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func Test_time(t *testing.T) {
+	e := StdEnv()
+	e.SetClock(&fake_clock{time.Date(2024, 2, 28, 23, 30, 0, 0, time.UTC)})
+
+	examples := [][]string{
+		{"(current-time)", "#<time 2024-02-28T23:30:00Z>"},
+		{"(define j (current-jiffy))", "1709163000000000000"},
+		{"(jiffies-per-second)", "1000000000"},
+		{"(begin (sleep 1/2) (sleep (parse-duration \"1m\")) (- (current-jiffy) j))", "60500000000"},
+		{"(define t0 (parse-time \"2024-01-31 10:00:00\" 'datetime))", "#<time 2024-01-31T10:00:00Z>"},
+		{"(parse-time \"2024-01-31 10:00\" \"2006-01-02 15:04\" \"Europe/Berlin\")", "#<time 2024-01-31T10:00:00+01:00>"},
+		{"(format-time t0 \"Jan 2, 2006 at 3:04pm\")", "\"Jan 31, 2024 at 10:00am\""},
+		{"(format-time (time-in-zone t0 \"Asia/Tokyo\") 'rfc3339)", "\"2024-01-31T19:00:00+09:00\""},
+		{"(time-add-date t0 0 1 0)", "#<time 2024-03-02T10:00:00Z>"},
+		{"(time-add t0 -90)", "#<time 2024-01-31T09:58:30Z>"},
+		{"(time-difference (time-add t0 (parse-duration \"1h30m\")) t0)", "#<duration 1h30m0s>"},
+		{"(duration->seconds (make-duration 1.25))", "5/4"},
+		{"(list (time<? t0 (current-time)) (time=? t0 (time-in-zone t0 \"Asia/Tokyo\")) (equal? t0 (make-time 2024 1 31 10)))", "'(t t t)"},
+		{"(list (time-year t0) (time-month t0) (time-day t0) (time-hour t0) (time-weekday t0) (time-zone-name t0))", "'(2024 1 31 10 wednesday \"UTC\")"},
+		{"(time->seconds (make-time 1970 1 1 0 0 1 500000000))", "3/2"},
+		{"(seconds->time 86400)", "#<time 1970-01-02T00:00:00Z>"},
+		{"(list (time->seconds (make-time 3000 1 1)) (time->seconds (make-time 1500 1 1)))", "'(32503680000 -14831769600)"},
+		{"(list (seconds->time 32503680000) (seconds->time -14831769600) (seconds->time -1/2))",
+			"'(#<time 3000-01-01T00:00:00Z> #<time 1500-01-01T00:00:00Z> #<time 1969-12-31T23:59:59.5Z>)"},
+		{"(list (time? t0) (duration? t0) (duration? (make-duration 1)))", "'(t false t)"},
+	}
+	run_eval_table(t, e, examples)
+}
@@ -10,8 +10,13 @@ import (
 
 type Bool = ast.Bool
 type Int = ast.Int
+type Decimal = ast.Decimal
+type Rat = ast.Rat
 type Float = ast.Float
+type ExactComplex = ast.ExactComplex
+type Complex = ast.Complex
 type Str = ast.Str
+type Char = ast.Char
 type Symbol = ast.Symbol
 type Any = ast.Any
 type List = ast.List
@@ -19,10 +24,12 @@ type NIl = ast.Nil
 
 type PureFunction = func(...Any) Any
 
+type NumberFormat = ast.NumberFormat
+
 func int_to_float(v Int) Float {
 	r := new(big.Rat)
 	r.SetInt(v.Value)
-	return Float{r}
+	return Float{Value: r}
 }
 
 func to_symbol(s Any) Symbol {
@@ -47,6 +54,8 @@ func to_function(s Any) PureFunction {
 	switch v := s.(type) {
 	case PureFunction:
 		return v
+	case *lambda:
+		return v.call
 	default:
 		panic("Invalid function: " + LispyStr(s) + fmt.Sprintf(" type: %v", reflect.TypeOf(v)) + "; probably evaluated the unquoted list")
 	}
@@ -67,8 +76,12 @@ func if_test(value Any) bool {
 		return v.Value.Sign() != 0
 	case Str:
 		return len(v.Value) > 0
-	case Float:
+	case Rat:
 		return v.Value.Sign() != 0
+	case Decimal:
+		return v.Unscaled.Sign() != 0
+	case Float:
+		return v.IsSpecial() || v.Value.Sign() != 0
 	default:
 		return true
 	}
@@ -86,6 +99,11 @@ func is_nil(value Any) bool {
 	}
 }
 
-func LispyStr(expr Any) string {
+// String representation of the expression,
+// numbers are printed with the format if it is provided
+func LispyStr(expr Any, format ...NumberFormat) string {
+	if len(format) > 0 {
+		return ast.Format(expr, format[0])
+	}
 	return ast.String(expr)
 }
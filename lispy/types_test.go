@@ -0,0 +1,46 @@
+package lispy
+
+import (
+	"testing"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+func Test_NumberFormat(t *testing.T) {
+	examples := [][]string{
+		{"full", "(list 1/2 0.5 #d1.50 0.1)", "'(1/2 0.5 1.50 0.1000000000000000000013552527156068805425093160010874271392822266)"},
+		{"shortest", "(list pi 0.1 (/ 1.0 3) 1.5+2i)", "'(3.141592653589793 0.1 0.3333333333333333 1.5+2.0i)"},
+		{"fixed", "(list pi 2/3 -0.5)", "'(3.142 2/3 -0.500)"},
+		{"scientific", "(list pi 1234.5)", "'(3.142e+00 1.234e+03)"},
+		{"exact", "(list 0.1 +inf.0 2)", "'(#i1/10 +inf.0 2)"},
+		{"shortest", "(list (number->string pi) (number->string 255 16) (number->string -5/3 2) (number->string 3+4i 8))",
+			"'(\"3.141592653589793\" \"ff\" \"-101/11\" \"3+4i\")"},
+	}
+	e := StdEnv()
+	// shortest is the default
+	run_eval_table(t, e, [][]string{
+		{"float-format", "shortest"},
+		{"(list pi 0.1 (number->string 0.1))", "'(3.141592653589793 0.1 \"0.1\")"},
+	})
+
+	e.Eval(ParseStr("(set! float-digits 3)"))
+	for _, test := range examples {
+		e.Eval(ParseStr("(set! float-format '" + test[0] + ")"))
+		result := e.Eval(ParseStr(test[1]))
+		res_str := LispyStr(result, e.NumberFormat())
+		if res_str != test[2] {
+			t.Errorf("Not expected %s result: %q -> %q, expected: %q", test[0], test[1], res_str, test[2])
+		}
+	}
+
+	// without the format numbers are printed as with the default one
+	lst := List{ast.FloatNum(1.5), ast.FloatNum(0.1), ast.FloatNum(1e21), ast.FloatNum(2)}
+	if s := LispyStr(lst); s != "(1.5 0.1 1e+21 2.0)" || s != LispyStr(lst, NumberFormat{}) || s != LispyStr(lst, StdEnv().NumberFormat()) {
+		t.Errorf("Unexpected LispyStr() without NumberFormat: %q", s)
+	}
+
+	f := NumberFormat{Float: ast.FloatFixed, Digits: 1}
+	if s := LispyStr(List{ast.FloatNum(0.25), ast.IntNum(-1)}, f); s != "(0.3 -1)" {
+		t.Errorf("Unexpected LispyStr() with NumberFormat: %q", s)
+	}
+}
@@ -0,0 +1,473 @@
+package lispy
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+// FromInt converts Go int into lispy integer
+func FromInt(i int) Int {
+	return ast.IntNum(int64(i))
+}
+
+var (
+	big_int_type  = reflect.TypeOf((*big.Int)(nil))
+	big_rat_type  = reflect.TypeOf((*big.Rat)(nil))
+	time_type     = reflect.TypeOf(time.Time{})
+	duration_type = reflect.TypeOf(time.Duration(0))
+	bytes_type    = reflect.TypeOf([]byte(nil))
+)
+
+// Struct field name from `lispy:"name"` tag, or Go field name.
+// Tag "-" skips the field, ",omitempty" skips zero value.
+func field_name(f reflect.StructField) (name string, omitempty bool, ok bool) {
+	if !f.IsExported() {
+		return "", false, false
+	}
+	tag := f.Tag.Get("lispy")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, opts == "omitempty", true
+}
+
+// ToValue converts Go value into lispy value:
+// numbers, strings, slices, maps, structs, pointers, time.Time and time.Duration.
+// Maps and structs become alists of (key value) with symbol keys, like JSON objects.
+// Lispy values are returned as is, channels and functions become opaque Go objects.
+// Values with pointer cycles can't be converted, this is an error.
+func ToValue(v any) Any {
+	return any_to_value(v, value_path{})
+}
+
+// Pointers, maps and slices on the way from the converted value, to detect cycles
+type value_path map[value_visit]bool
+
+type value_visit struct {
+	ptr uintptr
+	t   reflect.Type
+	len int
+}
+
+func any_to_value(v any, path value_path) Any {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case Bool, Int, Rat, Decimal, Float, ExactComplex, Complex, Str, Char, Symbol, List, ast.Nil, Time, Duration, Regexp,
+		PureFunction, *lambda, *InputPort, *OutputPort, EofObject, *Env, GoObject:
+		return x
+	}
+	return to_value(reflect.ValueOf(v), path)
+}
+
+func to_value(v reflect.Value, path value_path) Any {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if !v.IsNil() {
+			visit := value_visit{v.Pointer(), v.Type(), 0}
+			if v.Kind() == reflect.Slice {
+				visit.len = v.Len()
+			}
+			if path[visit] {
+				panic("Cannot convert Go value with a cycle: " + v.Type().String())
+			}
+			path[visit] = true
+			defer delete(path, visit)
+		}
+	}
+
+	switch v.Type() {
+	case big_int_type:
+		if v.IsNil() {
+			return nil
+		}
+		return Int{new(big.Int).Set(v.Interface().(*big.Int))}
+	case big_rat_type:
+		if v.IsNil() {
+			return nil
+		}
+		return ast.ExactRat(new(big.Rat).Set(v.Interface().(*big.Rat)))
+	case time_type:
+		return Time{v.Interface().(time.Time)}
+	case duration_type:
+		return Duration{time.Duration(v.Int())}
+	case bytes_type:
+		return Str{string(v.Bytes())}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return Bool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return ast.IntNum(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return Int{new(big.Int).SetUint64(v.Uint())}
+	case reflect.Float32, reflect.Float64:
+		return ast.FloatNum(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		return ast.ComplexNum(v.Complex())
+	case reflect.String:
+		return Str{v.String()}
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return List{}
+		}
+		lst := List{}
+		for i := 0; i < v.Len(); i++ {
+			lst = append(lst, to_value(v.Index(i), path))
+		}
+		return lst
+	case reflect.Map:
+		return map_to_value(v, path)
+	case reflect.Struct:
+		obj := List{}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, omitempty, ok := field_name(t.Field(i))
+			if !ok || (omitempty && v.Field(i).IsZero()) {
+				continue
+			}
+			obj = append(obj, List{Symbol{name}, to_value(v.Field(i), path)})
+		}
+		return obj
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return any_to_value(v.Elem().Interface(), path)
+	default:
+		// channels, functions and unsafe pointers are opaque
+		return GoObject{v}
+	}
+}
+
+// Alist sorted by keys, string keys become symbols
+func map_to_value(v reflect.Value, path value_path) Any {
+	obj := List{}
+	for _, k := range v.MapKeys() {
+		var key Any
+		if k.Kind() == reflect.String {
+			key = Symbol{k.String()}
+		} else {
+			key = to_value(k, path)
+		}
+		obj = append(obj, List{key, to_value(v.MapIndex(k), path)})
+	}
+	sort.Slice(obj, func(i, j int) bool {
+		return LispyStr(obj[i].(List)[0]) < LispyStr(obj[j].(List)[0])
+	})
+	return obj
+}
+
+// FromValue converts lispy value into Go value pointed by target, the reverse of ToValue.
+// Interface target gets natural Go representation: int64 or *big.Int, float64, string, []any.
+func FromValue(v Any, target any) error {
+	p := reflect.ValueOf(target)
+	if p.Kind() != reflect.Pointer || p.IsNil() {
+		return fmt.Errorf("FromValue target must be a non-nil pointer, got %T", target)
+	}
+	return from_value(v, p.Elem())
+}
+
+func conversion_error(v Any, t reflect.Type) error {
+	return fmt.Errorf("Cannot convert %s to %s", LispyStr(v), t)
+}
+
+// Symbol keys of alist by name
+func alist_items(v Any) (map[string]Any, bool) {
+	lst, ok := v.(List)
+	if !ok {
+		return nil, false
+	}
+	items := map[string]Any{}
+	for _, item := range lst {
+		kv, ok := item.(List)
+		if !ok || len(kv) != 2 {
+			return nil, false
+		}
+		switch k := kv[0].(type) {
+		case Symbol:
+			items[k.Name] = kv[1]
+		case Str:
+			items[k.Value] = kv[1]
+		default:
+			return nil, false
+		}
+	}
+	return items, true
+}
+
+func exact_int(v Any) (*big.Int, bool) {
+	switch x := v.(type) {
+	case Int:
+		return x.Value, true
+	case Char:
+		return big.NewInt(int64(x)), true
+	}
+	return nil, false
+}
+
+func from_value(v Any, dst reflect.Value) error {
+	if q, ok := v.(ast.Quote); ok {
+		// Eval() returns quoted lists
+		v = q.Value
+	}
+	t := dst.Type()
+	if l, ok := v.(*lambda); ok && t.Kind() == reflect.Func {
+		v = PureFunction(l.call)
+	}
+	if v != nil && t.Kind() != reflect.Interface && reflect.TypeOf(v).AssignableTo(t) {
+		// lispy values, functions and ports are passed as is
+		dst.Set(reflect.ValueOf(v))
+		return nil
+	}
+	if o, ok := v.(GoObject); ok {
+		if !o.value.Type().AssignableTo(t) {
+			return conversion_error(v, t)
+		}
+		dst.Set(o.value)
+		return nil
+	}
+	if v == nil || v == (ast.Nil{}) {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			dst.Set(reflect.Zero(t))
+			return nil
+		}
+		return conversion_error(v, t)
+	}
+
+	switch t {
+	case big_int_type:
+		i, ok := exact_int(v)
+		if !ok {
+			return conversion_error(v, t)
+		}
+		dst.Set(reflect.ValueOf(new(big.Int).Set(i)))
+		return nil
+	case big_rat_type:
+		if num_kind_ok(v) > num_rat {
+			return conversion_error(v, t)
+		}
+		dst.Set(reflect.ValueOf(new(big.Rat).Set(to_rat(v))))
+		return nil
+	case time_type:
+		tm, ok := v.(Time)
+		if !ok {
+			return conversion_error(v, t)
+		}
+		dst.Set(reflect.ValueOf(tm.Time))
+		return nil
+	case duration_type:
+		d, ok := v.(Duration)
+		if !ok {
+			return conversion_error(v, t)
+		}
+		dst.SetInt(int64(d.Duration))
+		return nil
+	case bytes_type:
+		s, ok := v.(Str)
+		if !ok {
+			return conversion_error(v, t)
+		}
+		dst.SetBytes([]byte(s.Value))
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		g, err := to_go(v)
+		if err != nil {
+			return err
+		}
+		if g == nil {
+			dst.Set(reflect.Zero(t))
+		} else if reflect.TypeOf(g).Implements(t) {
+			dst.Set(reflect.ValueOf(g))
+		} else {
+			return conversion_error(v, t)
+		}
+	case reflect.Bool:
+		b, ok := v.(Bool)
+		if !ok {
+			return conversion_error(v, t)
+		}
+		dst.SetBool(bool(b))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := exact_int(v)
+		if !ok || !i.IsInt64() || dst.OverflowInt(i.Int64()) {
+			return conversion_error(v, t)
+		}
+		dst.SetInt(i.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, ok := exact_int(v)
+		if !ok || !i.IsUint64() || dst.OverflowUint(i.Uint64()) {
+			return conversion_error(v, t)
+		}
+		dst.SetUint(i.Uint64())
+	case reflect.Float32, reflect.Float64:
+		if num_kind_ok(v) > num_float {
+			return conversion_error(v, t)
+		}
+		dst.SetFloat(to_float(v).Float64())
+	case reflect.Complex64, reflect.Complex128:
+		if num_kind_ok(v) > num_complex {
+			return conversion_error(v, t)
+		}
+		dst.SetComplex(to_complex128(v))
+	case reflect.String:
+		switch x := v.(type) {
+		case Str:
+			dst.SetString(x.Value)
+		case Symbol:
+			dst.SetString(x.Name)
+		case Char:
+			dst.SetString(string(rune(x)))
+		default:
+			return conversion_error(v, t)
+		}
+	case reflect.Slice:
+		lst, ok := v.(List)
+		if !ok {
+			return conversion_error(v, t)
+		}
+		s := reflect.MakeSlice(t, len(lst), len(lst))
+		for i, item := range lst {
+			if err := from_value(item, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(s)
+	case reflect.Array:
+		lst, ok := v.(List)
+		if !ok || len(lst) != t.Len() {
+			return conversion_error(v, t)
+		}
+		for i, item := range lst {
+			if err := from_value(item, dst.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		return alist_to_map(v, dst)
+	case reflect.Struct:
+		items, ok := alist_items(v)
+		if !ok {
+			return conversion_error(v, t)
+		}
+		for i := 0; i < t.NumField(); i++ {
+			name, _, ok := field_name(t.Field(i))
+			if !ok {
+				continue
+			}
+			item, found := items[name]
+			if !found {
+				continue
+			}
+			if err := from_value(item, dst.Field(i)); err != nil {
+				return fmt.Errorf("%s.%s: %w", t, t.Field(i).Name, err)
+			}
+		}
+	case reflect.Pointer:
+		p := reflect.New(t.Elem())
+		if err := from_value(v, p.Elem()); err != nil {
+			return err
+		}
+		dst.Set(p)
+	default:
+		return conversion_error(v, t)
+	}
+	return nil
+}
+
+func alist_to_map(v Any, dst reflect.Value) error {
+	t := dst.Type()
+	lst, ok := v.(List)
+	if !ok {
+		return conversion_error(v, t)
+	}
+	m := reflect.MakeMap(t)
+	for _, item := range lst {
+		kv, ok := item.(List)
+		if !ok || len(kv) != 2 {
+			return conversion_error(v, t)
+		}
+		key := reflect.New(t.Key()).Elem()
+		if err := from_value(kv[0], key); err != nil {
+			return err
+		}
+		value := reflect.New(t.Elem()).Elem()
+		if err := from_value(kv[1], value); err != nil {
+			return err
+		}
+		m.SetMapIndex(key, value)
+	}
+	dst.Set(m)
+	return nil
+}
+
+// Natural Go representation of lispy value
+func to_go(v Any) (any, error) {
+	switch x := v.(type) {
+	case nil, ast.Nil:
+		return nil, nil
+	case Bool:
+		return bool(x), nil
+	case Int:
+		if x.Value.IsInt64() {
+			return x.Value.Int64(), nil
+		}
+		return new(big.Int).Set(x.Value), nil
+	case Rat:
+		return new(big.Rat).Set(x.Value), nil
+	case Decimal:
+		return x.Rat(), nil
+	case Float:
+		return x.Float64(), nil
+	case ExactComplex, Complex:
+		return to_complex128(x), nil
+	case Str:
+		return x.Value, nil
+	case Symbol:
+		return x.Name, nil
+	case Char:
+		return rune(x), nil
+	case Time:
+		return x.Time, nil
+	case Duration:
+		return x.Duration, nil
+	case GoObject:
+		return x.Value(), nil
+	case List:
+		r := []any{}
+		for _, item := range x {
+			g, err := to_go(item)
+			if err != nil {
+				return nil, err
+			}
+			r = append(r, g)
+		}
+		return r, nil
+	default:
+		return v, nil
+	}
+}
+
+// Numeric kind without panic, greater than any kind if not a number
+func num_kind_ok(v Any) int {
+	switch v.(type) {
+	case Int, Rat, Decimal, Float, ExactComplex, Complex:
+		return num_kind("", v)
+	}
+	return int(^uint(0) >> 1)
+}
@@ -0,0 +1,113 @@
+package lispy
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agutikov/go-lisp-experiments/lispy/syntax/ast"
+)
+
+type test_point struct {
+	X, Y int
+}
+
+type test_record struct {
+	Name     string             `lispy:"name"`
+	Tags     []string           `lispy:"tags"`
+	Scores   map[string]float64 `lispy:"scores"`
+	Big      *big.Int           `lispy:"big"`
+	Origin   test_point         `lispy:"origin"`
+	Next     *test_point        `lispy:"next,omitempty"`
+	Timeout  time.Duration      `lispy:"timeout"`
+	Secret   string             `lispy:"-"`
+	internal int
+}
+
+type test_node struct {
+	Name string
+	Next *test_node
+	Also *test_node
+}
+
+func Test_values(t *testing.T) {
+	big_value, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	rec := test_record{
+		Name:    "x",
+		Tags:    []string{"a", "b"},
+		Scores:  map[string]float64{"q": 0.5, "p": 2},
+		Big:     big_value,
+		Origin:  test_point{1, -2},
+		Timeout: time.Second,
+		Secret:  "s",
+	}
+	v := ToValue(rec)
+	expected := "((name \"x\") (tags (\"a\" \"b\")) (scores ((p 2.0) (q 0.5))) (big 123456789012345678901234567890) (origin ((X 1) (Y -2))) (timeout #<duration 1s>))"
+	if LispyStr(v, NumberFormat{Float: ast.FloatShortest}) != expected {
+		t.Errorf("Unexpected ToValue() result: %s", LispyStr(v, NumberFormat{Float: ast.FloatShortest}))
+	}
+
+	var back test_record
+	if err := FromValue(v, &back); err != nil {
+		t.Fatalf("FromValue() failed: %v", err)
+	}
+	rec.Secret = ""
+	if !reflect.DeepEqual(back, rec) {
+		t.Errorf("Not round-tripped: %+v", back)
+	}
+
+	// values from the interpreter
+	e := StdEnv()
+	var ints []int
+	if err := FromValue(e.Eval(ParseStr("(map (lambda (x) (* x x)) (list 1 2 3))")), &ints); err != nil || !reflect.DeepEqual(ints, []int{1, 4, 9}) {
+		t.Errorf("Unexpected FromValue() result: %v, %v", ints, err)
+	}
+	var p *test_point
+	if err := FromValue(e.Eval(ParseStr("'((X 3) (Y 4))")), &p); err != nil || *p != (test_point{3, 4}) {
+		t.Errorf("Unexpected FromValue() result: %v, %v", p, err)
+	}
+	var x any
+	if err := FromValue(e.Eval(ParseStr("'(1 \"s\" 1.5 sym)")), &x); err != nil || !reflect.DeepEqual(x, []any{int64(1), "s", 1.5, "sym"}) {
+		t.Errorf("Unexpected FromValue() result: %#v, %v", x, err)
+	}
+
+	var small int8
+	if err := FromValue(FromInt(300), &small); err == nil {
+		t.Errorf("Expected overflow error")
+	}
+	var s string
+	if err := FromValue(FromInt(1), &s); err == nil {
+		t.Errorf("Expected conversion error")
+	}
+
+	// Go values work with arithmetic
+	sum := Lambda("(lambda (lst) (apply + lst))")
+	if r := LispyStr(sum(ToValue([]int{1, 2, 3}))); r != "6" {
+		t.Errorf("Unexpected sum: %s", r)
+	}
+
+	// shared pointers are converted, cycles are errors
+	leaf := &test_node{Name: "leaf"}
+	if r := LispyStr(ToValue(test_node{Name: "root", Next: leaf, Also: leaf})); r != "((Name \"root\") (Next ((Name \"leaf\") (Next nil) (Also nil))) (Also ((Name \"leaf\") (Next nil) (Also nil))))" {
+		t.Errorf("Unexpected shared pointers conversion: %s", r)
+	}
+	loop := &test_node{Name: "loop"}
+	loop.Next = &test_node{Name: "back", Next: loop}
+	self := []any{1, nil}
+	self[1] = self
+	m := map[string]any{}
+	m["m"] = m
+	for _, v := range []any{loop, self, m} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "cycle") {
+					t.Errorf("Expected cycle error for %T, got: %v", v, r)
+				}
+			}()
+			ToValue(v)
+		}()
+	}
+}
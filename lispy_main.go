@@ -1,44 +1,58 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/agutikov/go-lisp-experiments/lispy"
 	"github.com/agutikov/go-lisp-experiments/cmdlex"
 )
 
+// (exit) unwinds to here and terminates the process
+func exit_on_request(r any) {
+	if e, ok := r.(lispy.Exit); ok {
+		os.Exit(e.Code)
+	}
+}
+
 func exec(env *lispy.Env, line string) {
 	defer func() {
 		if r := recover(); r != nil {
+			exit_on_request(r)
 			fmt.Println(r)
 		}
 	}()
 
 	expr := lispy.ParseStr(line)
 	r := env.Eval(expr)
-	fmt.Println(lispy.LispyStr(r))
+	fmt.Println(lispy.LispyStr(r, env.NumberFormat()))
 }
 
-func exec_file(env *lispy.Env, filename string) {
+// Returns false if the script failed
+func exec_file(env *lispy.Env, filename string) (ok bool) {
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Println(r)
+			exit_on_request(r)
+			fmt.Fprintln(os.Stderr, r)
+			ok = false
 		}
 	}()
 
 	expr := lispy.ParseFile(filename)
 	r := env.Eval(expr)
-	fmt.Println(lispy.LispyStr(r))
+	fmt.Println(lispy.LispyStr(r, env.NumberFormat()))
+	return true
 }
 
+// Lines are read from the current input port, so (read-line) in the REPL gets the next line
 func repl(env *lispy.Env) {
-	reader := bufio.NewReader(os.Stdin)
+	input := env.CurrentInputPort()
 	for {
 		fmt.Print("go-lis.py> ")
-		line, err := reader.ReadString('\n')
+		line, err := input.ReadLine()
 		if err != nil {
 			fmt.Println(err)
 			break
@@ -52,12 +66,94 @@ func repl(env *lispy.Env) {
 	}
 }
 
+// Splits interpreter arguments and the script name followed by script arguments
+func split_script_args(args []string) ([]string, []string) {
+	for i := 1; i < len(args); i++ {
+		if args[i] == "-e" || args[i] == "--e" {
+			// skip the expression
+			i++
+		} else if args[i] == "-" || !strings.HasPrefix(args[i], "-") {
+			return args[:i], args[i:]
+		}
+	}
+	return args, nil
+}
+
+const gen_usage = `usage: go-lispy gen [-pkg name] [-var name] [-o file.go] file.lsp
+  (if a file named gen exists in the current directory, "go-lispy gen" runs it as a script)`
 
+// "gen" is the subcommand unless there is a script with this name
+func is_gen_command(args []string) bool {
+	if len(args) < 2 || args[1] != "gen" {
+		return false
+	}
+	_, err := os.Stat(args[1])
+	return err != nil
+}
+
+// go-lispy gen [-pkg name] [-var name] [-o file.go] file.lsp
+// writes Go source with pre-built AST of the lispy file, e.g. for go:generate
+func gen(args []string) (ok bool) {
+	flags := flag.NewFlagSet("gen", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), gen_usage)
+		flags.PrintDefaults()
+	}
+	pkg := flags.String("pkg", os.Getenv("GOPACKAGE"), "package name, $GOPACKAGE by default")
+	name := flags.String("var", "", "variable name, file name with '_lsp' suffix by default")
+	out := flags.String("o", "", "output file, file name with '_lsp.go' suffix by default")
+	if flags.Parse(args) != nil {
+		return false
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return false
+	}
+	filename := flags.Arg(0)
+	base := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	if *pkg == "" {
+		*pkg = "main"
+	}
+	if *name == "" {
+		*name = strings.NewReplacer("-", "_", ".", "_").Replace(base) + "_lsp"
+	}
+	if *out == "" {
+		*out = strings.TrimSuffix(filename, filepath.Ext(filename)) + "_lsp.go"
+	}
+
+	text, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			// parse errors are reported as "line:column: error: ..."
+			fmt.Fprintf(os.Stderr, "%s:%v\n", filename, r)
+			ok = false
+		}
+	}()
+	src, err := lispy.GenerateGo(*pkg, *name, filepath.ToSlash(filename), lispy.ParseStr(string(text)))
+	if err == nil {
+		err = os.WriteFile(*out, src, 0644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	return true
+}
 
 func main() {
-	args := cmdlex.ParseCmdLineArgs(os.Args, 0)
+	if is_gen_command(os.Args) {
+		if !gen(os.Args[2:]) {
+			os.Exit(1)
+		}
+		return
+	}
 
-	fmt.Printf("%+v\n", args)
+	interp_args, script := split_script_args(os.Args)
+	args := cmdlex.ParseCmdLineArgs(interp_args, 0)
 
 	env := lispy.StdEnv()
 
@@ -66,15 +162,14 @@ func main() {
 		exec(env, strings.Join(exprs, "\n"))
 	}
 
-	for _, filename := range args.Positional[1:] {
-		if filename == "-" {
+	if len(script) > 0 {
+		env.SetCommandLine(script[1:])
+		if script[0] == "-" {
 			repl(env)
-		} else {
-			exec_file(env, filename)
+		} else if !exec_file(env, script[0]) {
+			os.Exit(1)
 		}
-	}
-
-	if len(args.Positional) == 1 && len(args.Options) == 0 {
+	} else if len(args.Options) == 0 {
 		// No other options - run repl
 		repl(env)
 	}